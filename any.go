@@ -0,0 +1,133 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type Any struct {
+	TypeUrl string `protobuf:"bytes,1,opt,name=type_url,proto3"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+const (
+	AnyTypeKey       = "@type"
+	AnyTypeURLPrefix = "type.googleapis.com/"
+)
+
+var (
+	anyName = TypeName(reflect.TypeFor[Any]())
+)
+
+func Pack(v any, opts ...MarshalOption) (*Any, error) {
+	reflected := reflect.ValueOf(v)
+	if reflected.Kind() == reflect.Pointer {
+		reflected = reflected.Elem()
+	}
+	if newMarshalOptions(opts).registry().CaptureType(reflected.Type()) == nil {
+		return nil, fmt.Errorf("type %s is not registered", displayName(reflected.Type()))
+	}
+	value, err := Marshal(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out := new(Any)
+	out.TypeUrl = AnyTypeURLPrefix + messageName(reflected.Type())
+	out.Value = value
+	return out, nil
+}
+
+func Unpack(a *Any, opts ...UnmarshalOption) (any, error) {
+	typ := newUnmarshalOptions(opts).registry().resolveAny(a.TypeUrl)
+	if typ == nil || typ.reflectType == nil {
+		return nil, fmt.Errorf("type %s is not registered", a.TypeUrl)
+	}
+	out := reflect.New(typ.reflectType)
+	if err := Unmarshal(a.Value, out.Interface(), opts...); err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+func UnpackTo(a *Any, v any, opts ...UnmarshalOption) error {
+	reflected := reflect.ValueOf(v)
+	if reflected.Kind() == reflect.Pointer {
+		reflected = reflected.Elem()
+	}
+	registry := newUnmarshalOptions(opts).registry()
+	if typ := registry.resolveAny(a.TypeUrl); typ == nil || typ != registry.CaptureType(reflected.Type()) {
+		return fmt.Errorf("cannot unpack %s into %s", a.TypeUrl, displayName(reflected.Type()))
+	}
+	return Unmarshal(a.Value, v, opts...)
+}
+
+func displayName(t reflect.Type) string {
+	if len(t.Name()) == 0 {
+		return t.String()
+	}
+	return TypeName(t)
+}
+
+func messageName(t reflect.Type) string {
+	if named, ok := reflect.New(t).Interface().(interface{ XXX_MessageName() string }); ok {
+		return named.XXX_MessageName()
+	}
+	return TypeName(t)
+}
+
+func (r *Registry) resolveAny(typeUrl string) *Type {
+	state := r.state.Load()
+	name := strings.TrimPrefix(typeUrl, AnyTypeURLPrefix)
+	if typ := state.names[name]; typ != nil {
+		return typ
+	}
+	if i := strings.LastIndex(typeUrl, "/"); i >= 0 {
+		name = typeUrl[i+1:]
+	}
+	if typeName, ok := state.messageNames[name]; ok {
+		return state.names[typeName]
+	}
+	return nil
+}
+
+func readAny(bytes []byte, options *UnmarshalOptions) (map[string]any, error) {
+	a := new(Any)
+	if err := unmarshal(bytes, reflect.ValueOf(a).Elem(), options); err != nil {
+		return nil, err
+	}
+	typ := options.registry().resolveAny(a.TypeUrl)
+	if typ == nil {
+		return read(anyName, bytes, options)
+	}
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	out, err := read(typ.Name, a.Value, options)
+	if err != nil {
+		return nil, err
+	}
+	out[AnyTypeKey] = a.TypeUrl
+	return out, nil
+}
+
+func writeAny(v map[string]any, options *MarshalOptions) ([]byte, error) {
+	typeUrl, ok := v[AnyTypeKey].(string)
+	if !ok {
+		return write(anyName, v, options)
+	}
+	typ := options.registry().resolveAny(typeUrl)
+	if typ == nil {
+		return nil, fmt.Errorf("type %s is not registered", typeUrl)
+	}
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	value, err := write(typ.Name, v, options)
+	options.leave()
+	if err != nil {
+		return nil, err
+	}
+	return marshal(make([]byte, 0), reflect.ValueOf(&Any{TypeUrl: typeUrl, Value: value}).Elem(), options)
+}
@@ -0,0 +1,71 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPackUnregistered(t *testing.T) {
+	type unregistered struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{&unregistered{}, "type github.com/vedadiyan/protolizer.unregistered is not registered"},
+		{&struct{ V int32 }{}, "type struct { V int32 } is not registered"},
+	}
+	for _, tt := range tests {
+		if _, err := Pack(tt.value); err == nil || err.Error() != tt.want {
+			t.Errorf("Pack(%T) error = %v, want %q", tt.value, err, tt.want)
+		}
+	}
+}
+
+type (
+	anyPayload struct {
+		Data []byte `protobuf:"bytes,1,opt,name=data,proto3"`
+	}
+	anyHolder struct {
+		Item *Any `protobuf:"bytes,1,opt,name=item,proto3"`
+	}
+)
+
+func TestAnyReadWriteOptions(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterType(reflect.TypeFor[anyPayload]())
+	r.RegisterType(reflect.TypeFor[anyHolder]())
+	packed, err := Pack(&anyPayload{Data: []byte("abc")}, WithMarshalRegistry(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := r.Marshal(&anyHolder{Item: packed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := TypeName(reflect.TypeFor[anyHolder]())
+	got, err := r.Read(name, data, WithAliasBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, _ := got["Item"].(map[string]any)
+	if item[AnyTypeKey] != packed.TypeUrl || string(item["Data"].([]byte)) != "abc" {
+		t.Fatalf("Read = %#v", got)
+	}
+	data[len(data)-1] = 'x'
+	if string(item["Data"].([]byte)) != "abx" {
+		t.Fatal("Data does not alias the input with WithAliasBytes")
+	}
+	out, err := r.Write(name, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("Write = %x, want %x", out, data)
+	}
+	if _, err := r.Read(name, data, WithUnmarshalMaxDepth(2)); err == nil {
+		t.Fatal("expected a depth error")
+	}
+}
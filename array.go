@@ -0,0 +1,30 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func arrayBytes(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	out := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(out), v)
+	return out
+}
+
+func setArray(v reflect.Value, items reflect.Value) error {
+	if items.Len() != v.Len() {
+		return fmt.Errorf("expected %d elements for %v but got %d", v.Len(), v.Type(), items.Len())
+	}
+	reflect.Copy(v, items)
+	return nil
+}
+
+func checkArrayLength(field *Field, length int) error {
+	if field.Kind == reflect.Array && field.Length != 0 && length != field.Length {
+		return fmt.Errorf("expected %d elements for field %s but got %d", field.Length, field.Name, length)
+	}
+	return nil
+}
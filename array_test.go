@@ -0,0 +1,70 @@
+package protolizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type arrayMessage struct {
+	Hash     [4]byte   `protobuf:"bytes,1,opt,name=hash,proto3"`
+	Point    [2]int32  `protobuf:"varint,2,rep,packed,name=point,proto3"`
+	HashPtr  *[4]byte  `protobuf:"bytes,3,opt,name=hash_ptr,proto3"`
+	PointPtr *[2]int32 `protobuf:"varint,4,rep,packed,name=point_ptr,proto3"`
+}
+
+func init() {
+	RegisterTypeFor[arrayMessage]()
+}
+
+func TestArrayGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *arrayMessage
+		want  string
+	}{
+		{"unset", &arrayMessage{}, ""},
+		{"bytes", &arrayMessage{Hash: [4]byte{1, 2, 3, 4}}, "0a0401020304"},
+		{"numbers", &arrayMessage{Point: [2]int32{1, -1}}, "120b01ffffffffffffffffff01"},
+		{"pointers", &arrayMessage{HashPtr: &[4]byte{5, 6, 7, 8}, PointPtr: &[2]int32{3, 4}}, "1a040506070822020304"},
+		{"zero pointers", &arrayMessage{HashPtr: &[4]byte{}, PointPtr: &[2]int32{}}, "1a040000000022020000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[arrayMessage](t, want)
+		})
+	}
+}
+
+func TestArrayUnpacked(t *testing.T) {
+	assertUnmarshal(t, decodeHex(t, "100110022003200a"), &arrayMessage{Point: [2]int32{1, 2}, PointPtr: &[2]int32{3, 10}})
+}
+
+func TestArrayLengthMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"short bytes", "0a03010203"},
+		{"long bytes", "0a050102030405"},
+		{"short numbers", "120101"},
+		{"long numbers", "1203010203"},
+		{"short bytes pointer", "1a0105"},
+		{"long numbers pointer", "2203030405"},
+	}
+	name := TypeName(reflect.TypeFor[arrayMessage]())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := decodeHex(t, tt.data)
+			if err := Unmarshal(data, new(arrayMessage)); err == nil || !strings.Contains(err.Error(), "expected") {
+				t.Fatalf("Unmarshal error = %v, want a length error", err)
+			}
+			if _, err := Read(name, data); err == nil || !strings.Contains(err.Error(), "expected") {
+				t.Fatalf("Read error = %v, want a length error", err)
+			}
+		})
+	}
+}
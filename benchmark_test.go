@@ -0,0 +1,132 @@
+package protolizer
+
+import "testing"
+
+type (
+	Person struct {
+		Name  string `protobuf:"bytes,1,opt,name=name,proto3"`
+		Age   int32  `protobuf:"varint,2,opt,name=age,proto3"`
+		Email string `protobuf:"bytes,3,opt,name=email,proto3"`
+	}
+	Address struct {
+		Street  string `protobuf:"bytes,1,opt,name=street,proto3"`
+		City    string `protobuf:"bytes,2,opt,name=city,proto3"`
+		Country string `protobuf:"bytes,3,opt,name=country,proto3"`
+	}
+	Contact struct {
+		Person   Person            `protobuf:"bytes,1,opt,name=person,proto3"`
+		Address  *Address          `protobuf:"bytes,2,opt,name=address,proto3"`
+		Phones   []string          `protobuf:"bytes,3,rep,name=phones,proto3"`
+		Metadata map[string]string `protobuf:"bytes,4,rep,name=metadata,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	}
+)
+
+var (
+	_person  = Person{Name: "John Doe", Age: 30, Email: "john@example.com"}
+	_contact = Contact{
+		Person:   _person,
+		Address:  &Address{Street: "1 Main St", City: "Springfield", Country: "US"},
+		Phones:   []string{"555-0100", "555-0101"},
+		Metadata: map[string]string{"source": "import"},
+	}
+)
+
+func init() {
+	RegisterTypeFor[Person]()
+	RegisterTypeFor[Address]()
+	RegisterTypeFor[Contact]()
+}
+
+func benchmarkCodecs(b *testing.B, run func(b *testing.B)) {
+	b.Run("compiled", run)
+	b.Run("reflect", func(b *testing.B) {
+		withoutCodecs(b, CaptureTypeFor[Person](), CaptureTypeFor[Address](), CaptureTypeFor[Contact]())
+		run(b)
+	})
+}
+
+func BenchmarkPersonMarshal(b *testing.B) {
+	benchmarkCodecs(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := Marshal(&_person); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkPersonMarshalAppend(b *testing.B) {
+	benchmarkCodecs(b, func(b *testing.B) {
+		buf := make([]byte, 0, 256)
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := MarshalAppend(buf[:0], &_person); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkPersonUnmarshal(b *testing.B) {
+	data, err := Marshal(&_person)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkCodecs(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var out Person
+			if err := Unmarshal(data, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkContactMarshal(b *testing.B) {
+	benchmarkCodecs(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := Marshal(&_contact); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkContactUnmarshal(b *testing.B) {
+	data, err := Marshal(&_contact)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkCodecs(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var out Contact
+			if err := Unmarshal(data, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCodecContactUnmarshal(b *testing.B) {
+	codec, err := CodecFor[Contact]()
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := codec.Marshal(&_contact)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkCodecs(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var out Contact
+			if err := codec.Unmarshal(data, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
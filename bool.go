@@ -1,10 +1,14 @@
 package protolizer
 
 func encodeBool(value bool) []byte {
+	return appendBool(nil, value)
+}
+
+func appendBool(out []byte, value bool) []byte {
 	if value {
-		return encodeVarint(1)
+		return append(out, 1)
 	}
-	return encodeVarint(0)
+	return append(out, 0)
 }
 
 func decodeBool(data []byte, offset int) (bool, int, error) {
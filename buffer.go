@@ -0,0 +1,42 @@
+package protolizer
+
+import "sync"
+
+type Buffer struct {
+	bytes []byte
+}
+
+const (
+	maxPooledBufferSize = 64 << 10
+)
+
+var (
+	_buffers = sync.Pool{
+		New: func() any {
+			return new(Buffer)
+		},
+	}
+)
+
+func MarshalBuffer(v any, opts ...MarshalOption) (*Buffer, error) {
+	buffer := _buffers.Get().(*Buffer)
+	out, err := MarshalAppend(buffer.bytes[:0], v, opts...)
+	if err != nil {
+		buffer.Release()
+		return nil, err
+	}
+	buffer.bytes = out
+	return buffer, nil
+}
+
+func (b *Buffer) Bytes() []byte {
+	return b.bytes
+}
+
+func (b *Buffer) Release() {
+	if cap(b.bytes) > maxPooledBufferSize {
+		b.bytes = nil
+	}
+	b.bytes = b.bytes[:0]
+	_buffers.Put(b)
+}
@@ -0,0 +1,80 @@
+package protolizer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarshalBuffer(t *testing.T) {
+	values := []any{
+		&_person,
+		&Person{},
+		&Contact{Person: Person{Name: strings.Repeat("n", 1000)}, Phones: []string{"1", "2", "3"}},
+		&_person,
+	}
+	for _, v := range values {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := MarshalBuffer(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), want) {
+			t.Fatalf("MarshalBuffer(%T) = %x, want %x", v, b.Bytes(), want)
+		}
+		b.Release()
+	}
+}
+
+func TestMarshalBufferReuse(t *testing.T) {
+	var previous *byte
+	for range 100 {
+		b, err := MarshalBuffer(&_contact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := &b.Bytes()[0]
+		b.Release()
+		if first == previous {
+			return
+		}
+		previous = first
+	}
+	t.Fatal("MarshalBuffer never reused a released buffer")
+}
+
+func TestMarshalBufferGrowth(t *testing.T) {
+	small, err := MarshalBuffer(&_person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small.Release()
+	large := &Person{Name: strings.Repeat("x", maxPooledBufferSize)}
+	want, err := Marshal(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalBuffer(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Fatal("MarshalBuffer output differs from Marshal after growing")
+	}
+	b.Release()
+	if b.bytes != nil {
+		t.Fatalf("a released buffer of %d bytes was kept for the pool", len(want))
+	}
+}
+
+func TestMarshalBufferError(t *testing.T) {
+	type unregistered struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	if b, err := MarshalBuffer(&unregistered{}); err == nil || b != nil {
+		t.Fatalf("MarshalBuffer = %v, %v, want an error", b, err)
+	}
+}
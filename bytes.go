@@ -2,18 +2,45 @@ package protolizer
 
 import (
 	"fmt"
+	"unsafe"
 )
 
 func encodeBytes(value []byte) []byte {
-	length := encodeVarint(int64(len(value)))
-	return append(length, value...)
+	return appendBytes(make([]byte, 0, sizeUvarint(uint64(len(value)))+len(value)), value)
 }
 
 func encodeString(value string) []byte {
-	return encodeBytes([]byte(value))
+	return appendString(make([]byte, 0, sizeUvarint(uint64(len(value)))+len(value)), value)
+}
+
+func appendBytes(out []byte, value []byte) []byte {
+	return append(appendUvarint(out, uint64(len(value))), value...)
+}
+
+func appendString(out []byte, value string) []byte {
+	return append(appendUvarint(out, uint64(len(value))), value...)
+}
+
+func prefixLength(out []byte, start int) []byte {
+	length := len(out) - start
+	size := sizeUvarint(uint64(length))
+	out = append(out, make([]byte, size)...)
+	copy(out[start+size:], out[start:start+length])
+	appendUvarint(out[:start], uint64(length))
+	return out
 }
 
 func decodeBytes(data []byte, offset int) ([]byte, int, error) {
+	view, consumed, err := viewBytes(data, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	value := make([]byte, len(view))
+	copy(value, view)
+	return value, consumed, nil
+}
+
+func viewBytes(data []byte, offset int) ([]byte, int, error) {
 	length, lengthSize, err := decodeVarint(data, offset)
 	if err != nil {
 		return nil, 0, err
@@ -30,15 +57,31 @@ func decodeBytes(data []byte, offset int) ([]byte, int, error) {
 		return nil, 0, fmt.Errorf("insufficient bytes for length-prefixed data")
 	}
 
-	value := make([]byte, length)
-	copy(value, data[start:end])
-	return value, lengthSize + int(length), nil
+	return data[start:end:end], lengthSize + int(length), nil
 }
 
 func decodeString(data []byte, offset int) (string, int, error) {
-	bytes, consumed, err := decodeBytes(data, offset)
+	bytes, consumed, err := viewBytes(data, offset)
 	if err != nil {
 		return "", 0, err
 	}
 	return string(bytes), consumed, nil
 }
+
+func (uo *UnmarshalOptions) decodeBytes(data []byte, offset int) ([]byte, int, error) {
+	if uo.AliasBytes {
+		return viewBytes(data, offset)
+	}
+	return decodeBytes(data, offset)
+}
+
+func (uo *UnmarshalOptions) decodeString(data []byte, offset int) (string, int, error) {
+	if !uo.AliasStrings {
+		return decodeString(data, offset)
+	}
+	bytes, consumed, err := viewBytes(data, offset)
+	if err != nil {
+		return "", 0, err
+	}
+	return unsafe.String(unsafe.SliceData(bytes), len(bytes)), consumed, nil
+}
@@ -0,0 +1,136 @@
+package protolizer
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type (
+	aliasInner struct {
+		Blob []byte `protobuf:"bytes,1,opt,name=blob,proto3"`
+		Text string `protobuf:"bytes,2,opt,name=text,proto3"`
+	}
+	aliasMessage struct {
+		Data  []byte            `protobuf:"bytes,1,opt,name=data,proto3"`
+		Name  string            `protobuf:"bytes,2,opt,name=name,proto3"`
+		Blobs [][]byte          `protobuf:"bytes,3,rep,name=blobs,proto3"`
+		Tags  []string          `protobuf:"bytes,4,rep,name=tags,proto3"`
+		Meta  map[string]string `protobuf:"bytes,5,rep,name=meta,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+		Inner *aliasInner       `protobuf:"bytes,6,opt,name=inner,proto3"`
+	}
+)
+
+func init() {
+	RegisterTypeFor[aliasInner]()
+	RegisterTypeFor[aliasMessage]()
+}
+
+func TestAlias(t *testing.T) {
+	data, err := Marshal(&aliasMessage{
+		Data:  []byte("data"),
+		Name:  "name",
+		Blobs: [][]byte{[]byte("blob")},
+		Tags:  []string{"tag"},
+		Meta:  map[string]string{"key": "value"},
+		Inner: &aliasInner{Blob: []byte("inner"), Text: "text"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(t *testing.T) {
+		tests := []struct {
+			name    string
+			opts    []UnmarshalOption
+			bytes   bool
+			strings bool
+		}{
+			{"copy", nil, false, false},
+			{"bytes", []UnmarshalOption{WithAliasBytes()}, true, false},
+			{"strings", []UnmarshalOption{WithAliasStrings()}, false, true},
+			{"both", []UnmarshalOption{WithAliasBytes(), WithAliasStrings()}, true, true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := new(aliasMessage)
+				if err := Unmarshal(data, got, tt.opts...); err != nil {
+					t.Fatal(err)
+				}
+				var key, value string
+				for key, value = range got.Meta {
+				}
+				for name, aliased := range map[string]bool{
+					"Data":       aliasesBytes(data, got.Data),
+					"Blobs":      aliasesBytes(data, got.Blobs[0]),
+					"Inner.Blob": aliasesBytes(data, got.Inner.Blob),
+				} {
+					if aliased != tt.bytes {
+						t.Errorf("%s aliases the input = %v, want %v", name, aliased, tt.bytes)
+					}
+				}
+				for name, aliased := range map[string]bool{
+					"Name":       aliasesString(data, got.Name),
+					"Tags":       aliasesString(data, got.Tags[0]),
+					"Meta key":   aliasesString(data, key),
+					"Meta value": aliasesString(data, value),
+					"Inner.Text": aliasesString(data, got.Inner.Text),
+				} {
+					if aliased != tt.strings {
+						t.Errorf("%s aliases the input = %v, want %v", name, aliased, tt.strings)
+					}
+				}
+
+				read, err := Read(TypeName(reflect.TypeFor[aliasMessage]()), data, tt.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				inner := read["Inner"].(map[string]any)
+				if aliased := aliasesBytes(data, read["Data"].([]byte)) && aliasesBytes(data, inner["Blob"].([]byte)); aliased != tt.bytes {
+					t.Errorf("Read bytes alias the input = %v, want %v", aliased, tt.bytes)
+				}
+				if aliased := aliasesString(data, read["Name"].(string)) && aliasesString(data, inner["Text"].(string)); aliased != tt.strings {
+					t.Errorf("Read strings alias the input = %v, want %v", aliased, tt.strings)
+				}
+			})
+		}
+	}
+	t.Run("compiled", run)
+	t.Run("reflect", func(t *testing.T) {
+		withoutCodecs(t, CaptureTypeFor[aliasMessage](), CaptureTypeFor[aliasInner]())
+		run(t)
+	})
+}
+
+func TestAliasMutation(t *testing.T) {
+	data, err := Marshal(&aliasMessage{Data: []byte("abc")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, aliased := new(aliasMessage), new(aliasMessage)
+	if err := Unmarshal(data, copied); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(data, aliased, WithAliasBytes()); err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] = 'x'
+	if string(copied.Data) != "abc" {
+		t.Fatalf("copied Data = %q, want %q", copied.Data, "abc")
+	}
+	if string(aliased.Data) != "abx" {
+		t.Fatalf("aliased Data = %q, want %q", aliased.Data, "abx")
+	}
+}
+
+func aliasesBytes(data []byte, b []byte) bool {
+	return len(b) != 0 && within(data, unsafe.Pointer(unsafe.SliceData(b)))
+}
+
+func aliasesString(data []byte, s string) bool {
+	return len(s) != 0 && within(data, unsafe.Pointer(unsafe.StringData(s)))
+}
+
+func within(data []byte, p unsafe.Pointer) bool {
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	return uintptr(p) >= start && uintptr(p) < start+uintptr(len(data))
+}
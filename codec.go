@@ -3,240 +3,450 @@ package protolizer
 import (
 	"fmt"
 	"reflect"
+	"slices"
+	"time"
+	"unsafe"
 )
 
 type (
-	encodeOptions struct {
-		MapKeyWireType   WireType
-		MapValueWireType WireType
+	codecOptions struct {
+		MapKeyInfo   *ProtobufInfo
+		MapValueInfo *ProtobufInfo
 	}
-	encodeOption func(*encodeOptions)
+	codecOption func(*codecOptions)
 )
 
-func withMapWireTypes(key WireType, value WireType) encodeOption {
-	return func(eo *encodeOptions) {
-		eo.MapKeyWireType = key
-		eo.MapValueWireType = value
+func withMapInfo(key *ProtobufInfo, value *ProtobufInfo) codecOption {
+	return func(co *codecOptions) {
+		co.MapKeyInfo = key
+		co.MapValueInfo = value
 	}
 }
 
-func Marshal(v any) ([]byte, error) {
+func Marshal(v any, opts ...MarshalOption) ([]byte, error) {
+	return MarshalAppend(make([]byte, 0), v, opts...)
+}
+
+func MarshalAppend(dst []byte, v any, opts ...MarshalOption) ([]byte, error) {
 	reflected := reflect.ValueOf(v)
 	if reflected.Kind() == reflect.Pointer {
 		reflected = reflected.Elem()
 	}
-	typ := CaptureType(reflected.Type())
-	out := make([]byte, 0)
+	options := acquireMarshalOptions(opts)
+	defer options.release()
+	typ, err := options.registry().lookup(reflected.Type())
+	if err != nil {
+		return nil, err
+	}
+	return marshalAppend(dst, typ, reflected, options)
+}
+
+func marshalAppend(dst []byte, typ *Type, reflected reflect.Value, options *MarshalOptions) ([]byte, error) {
+	n, err := sizeType(typ, reflected, options)
+	if err != nil {
+		return nil, err
+	}
+	out, err := marshalType(slices.Grow(dst, n), typ, reflected, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != len(dst)+n {
+		return nil, fmt.Errorf("encoded %d bytes but expected %d", len(out)-len(dst), n)
+	}
+	if !options.AllowPartial {
+		if err := checkRequired(typ, reflected); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func marshal(out []byte, reflected reflect.Value, options *MarshalOptions) ([]byte, error) {
+	typ, err := options.registry().lookup(reflected.Type())
+	if err != nil {
+		return nil, err
+	}
+	return marshalType(out, typ, reflected, options)
+}
+
+func marshalType(out []byte, typ *Type, reflected reflect.Value, options *MarshalOptions) ([]byte, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	base := typ.base(reflected)
 	for _, i := range typ.Fields {
-		var opts []encodeOption
+		if codec := options.compiled(i, base); codec != nil {
+			var err error
+			out, err = codec.encode(out, unsafe.Add(base, codec.offset), reflected, options)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var opts []codecOption
 		v := reflected.FieldByIndex(i.FieldIndex)
-		if v.Kind() == reflect.Map {
-			opts = append(opts, withMapWireTypes(i.Tags.MapKey, i.Tags.MapValue))
+		if len(i.OneOf) != 0 {
+			v = i.oneOfCase(v)
+		}
+		if !i.isPresent(v) {
+			continue
 		}
-		w := i.Tags.Protobuf.WireType
-		if i.Kind == reflect.Slice {
-			w = WireTypeLen
+		if v.Kind() == reflect.Map && !i.Tags.Protobuf.isStruct(i.Key, i.Index) {
+			opts = append(opts, withMapInfo(i.Tags.mapKeyInfo(), i.Tags.mapValueInfo()))
 		}
-		tag, err := encodeTag(int32(i.Tags.Protobuf.FieldNum), w)
+		var err error
+		out, err = appendTag(out, int32(i.Tags.Protobuf.FieldNum), i.tagWireType())
 		if err != nil {
 			return nil, err
 		}
-		if v.IsZero() {
-			continue
-		}
 		if v.Kind() == reflect.Pointer {
 			v = v.Elem()
 		}
-		bytes, err := encodeValue(&v, i.Kind, i.Tags.Protobuf.FieldNum, i.Tags.Protobuf.WireType, opts...)
+		out, err = encodeValue(out, &v, i.Kind, i.Tags.Protobuf, options, opts...)
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, append(tag, bytes...)...)
+	}
+	if typ.UnknownFields != nil {
+		out = append(out, reflected.FieldByIndex(typ.UnknownFields).Bytes()...)
 	}
 	return out, nil
 }
 
-func encodeValue(v *reflect.Value, kind reflect.Kind, fieldNumber int, wireType WireType, opts ...encodeOption) ([]byte, error) {
+func encodeValue(out []byte, v *reflect.Value, kind reflect.Kind, info *ProtobufInfo, options *MarshalOptions, opts ...codecOption) ([]byte, error) {
+	fieldNumber, wireType := info.FieldNum, info.WireType
+	if info.Wrapper {
+		return encodeWrapper(out, v, kind, options)
+	}
+	if wireType == WireTypeLen {
+		if conv := converterFor(v.Type()); conv != nil {
+			return encodeConverted(out, v, conv, options)
+		}
+	}
 	switch kind {
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
 		{
+			if wireType == WireTypeLen && v.Type() == durationType {
+				return appendDuration(out, time.Duration(v.Int())), nil
+			}
 			if wireType == WireTypeI32 {
-				return encodeFixed32(int32(v.Int())), nil
+				return appendFixed32(out, int32(v.Int())), nil
 			}
 			if wireType == WireTypeI64 {
-				return encodeFixed64(int64(v.Int())), nil
+				return appendFixed64(out, int64(v.Int())), nil
+			}
+			if info.ZigZag {
+				return appendZigZag(out, v.Int()), nil
 			}
-			return encodeVarint(v.Int()), nil
+			return appendVarint(out, v.Int()), nil
 		}
 	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
 		{
 			if wireType == WireTypeI32 {
-				return encodeFixed32(int32(v.Uint())), nil
+				return appendFixed32(out, int32(v.Uint())), nil
 			}
 			if wireType == WireTypeI64 {
-				return encodeFixed64(int64(v.Uint())), nil
+				return appendFixed64(out, int64(v.Uint())), nil
 			}
-			return encodeUvarint(v.Uint()), nil
+			return appendUvarint(out, v.Uint()), nil
 		}
 	case reflect.Float32:
 		{
-			return encodeFloat32(float32(v.Float())), nil
+			return appendFloat32(out, float32(v.Float())), nil
 		}
 	case reflect.Float64:
 		{
-			return encodeFloat64(v.Float()), nil
+			return appendFloat64(out, v.Float()), nil
 		}
 	case reflect.Bool:
 		{
-			return encodeBool(v.Bool()), nil
+			return appendBool(out, v.Bool()), nil
 		}
 	case reflect.String:
 		{
-			return encodeString(v.String()), nil
+			return appendString(out, v.String()), nil
 		}
 	case reflect.Array, reflect.Slice:
 		{
 			k := v.Type().Elem().Kind()
+			if info.isListValue(k) {
+				out, start := options.beginLength(out)
+				out, err := encodeListValue(out, *v, options)
+				if err != nil {
+					return nil, err
+				}
+				return options.endLength(out, start), nil
+			}
 			if k == reflect.Uint8 {
-				return encodeBytes(v.Bytes()), nil
-			}
-			var data []byte
-			switch wireType {
-			case WireTypeVarint, WireTypeI32, WireTypeI64:
-				{
-					for i := 0; i < v.Len(); i++ {
-						v := v.Index(i)
-						if v.Kind() == reflect.Pointer {
-							v = v.Elem()
-						}
-						bytes, err := encodeValue(&v, v.Kind(), fieldNumber, wireType)
-						if err != nil {
-							return nil, err
-						}
-						data = append(data, bytes...)
+				return appendBytes(out, arrayBytes(*v)), nil
+			}
+			if info.isPacked() {
+				out, start := options.beginLength(out)
+				for i := 0; i < v.Len(); i++ {
+					v := v.Index(i)
+					if v.Kind() == reflect.Pointer {
+						v = v.Elem()
+					}
+					var err error
+					out, err = encodeValue(out, &v, v.Kind(), info, options)
+					if err != nil {
+						return nil, err
 					}
-					return encodeBytes(data), nil
-				}
-			default:
-				{
-					for i := 0; i < v.Len(); i++ {
-						if len(data) != 0 {
-							tag, err := encodeTag(int32(fieldNumber), WireTypeLen)
-							if err != nil {
-								return nil, err
-							}
-							data = append(data, tag...)
-						}
-						v := v.Index(i)
-						if v.Kind() == reflect.Pointer {
-							v = v.Elem()
-						}
-						bytes, err := encodeValue(&v, v.Kind(), fieldNumber, wireType)
-						if err != nil {
-							return nil, err
-						}
-						data = append(data, bytes...)
+				}
+				return options.endLength(out, start), nil
+			}
+			elemInfo := info.elementInfo(elementKinds(v.Type().Elem()))
+			for i := 0; i < v.Len(); i++ {
+				var err error
+				if i != 0 {
+					out, err = appendTag(out, int32(fieldNumber), wireType)
+					if err != nil {
+						return nil, err
 					}
-					return data, nil
+				}
+				v := v.Index(i)
+				if v.Kind() == reflect.Pointer {
+					v = v.Elem()
+				}
+				out, err = encodeValue(out, &v, v.Kind(), elemInfo, options)
+				if err != nil {
+					return nil, err
 				}
 			}
+			return out, nil
 
 		}
 	case reflect.Map:
 		{
-			encodeOptions := new(encodeOptions)
+			if info.isStruct(v.Type().Key().Kind(), v.Type().Elem().Kind()) {
+				out, start := options.beginLength(out)
+				out, err := encodeStruct(out, *v, options)
+				if err != nil {
+					return nil, err
+				}
+				return options.endLength(out, start), nil
+			}
+			codecOptions := new(codecOptions)
 			for _, opt := range opts {
-				opt(encodeOptions)
+				opt(codecOptions)
 			}
-			var data []byte
-			mapRange := v.MapRange()
-			for mapRange.Next() {
-				if len(data) != 0 {
-					tag, err := encodeTag(int32(fieldNumber), WireTypeLen)
+			for i, key := range options.mapKeys(*v) {
+				var start int
+				var err error
+				if i != 0 {
+					out, err = appendTag(out, int32(fieldNumber), WireTypeLen)
 					if err != nil {
 						return nil, err
 					}
-					data = append(data, tag...)
 				}
-				key := mapRange.Key()
+				value := v.MapIndex(key)
 				if key.Kind() == reflect.Pointer {
 					key = key.Elem()
 				}
-				keyTag, err := encodeTag(1, encodeOptions.MapKeyWireType)
+				out, start = options.beginLength(out)
+				out, err = appendTag(out, 1, codecOptions.MapKeyInfo.WireType)
 				if err != nil {
 					return nil, err
 				}
-				keyBytes, err := encodeValue(&key, key.Kind(), fieldNumber, encodeOptions.MapKeyWireType)
+				out, err = encodeValue(out, &key, key.Kind(), codecOptions.MapKeyInfo, options)
 				if err != nil {
 					return nil, err
 				}
-				value := mapRange.Value()
-				valueTag, err := encodeTag(2, encodeOptions.MapValueWireType)
+				out, err = appendTag(out, 2, codecOptions.MapValueInfo.WireType)
 				if err != nil {
 					return nil, err
 				}
 				if value.Kind() == reflect.Pointer {
+					if value.IsNil() {
+						value = reflect.New(value.Type().Elem())
+					}
 					value = value.Elem()
 				}
-				valueBytes, err := encodeValue(&value, value.Kind(), fieldNumber, encodeOptions.MapValueWireType)
+				out, err = encodeValue(out, &value, value.Kind(), codecOptions.MapValueInfo, options)
 				if err != nil {
 					return nil, err
 				}
-
-				keyEntry := append(keyTag, keyBytes...)
-				valueEntry := append(valueTag, valueBytes...)
-				entry := encodeBytes(append(keyEntry, valueEntry...))
-				data = append(data, entry...)
+				out = options.endLength(out, start)
 			}
-			return data, nil
+			return out, nil
 		}
 	case reflect.Struct:
 		{
-
-			data, err := Marshal(v.Interface())
+			if v.Type() == timeType {
+				return appendTimestamp(out, v.Interface().(time.Time))
+			}
+			if wireType == WireTypeSGroup {
+				out, err := marshal(out, *v, options)
+				if err != nil {
+					return nil, err
+				}
+				return appendTag(out, int32(fieldNumber), WireTypeEGroup)
+			}
+			out, start := options.beginLength(out)
+			out, err := marshal(out, *v, options)
 			if err != nil {
 				return nil, err
 			}
-			out := encodeBytes(data)
-			return out, nil
+			return options.endLength(out, start), nil
+		}
+	case reflect.Interface:
+		{
+			out, start := options.beginLength(out)
+			out, err := encodeStructValue(out, *v, options)
+			if err != nil {
+				return nil, err
+			}
+			return options.endLength(out, start), nil
 		}
 	}
 	return nil, fmt.Errorf("unexpected type %v", kind)
 }
 
-func Unmarshal(bytes []byte, v any) error {
+func Unmarshal(bytes []byte, v any, opts ...UnmarshalOption) error {
 	reflected := reflect.ValueOf(v)
 	if reflected.Kind() == reflect.Pointer {
 		reflected = reflected.Elem()
 	}
+	options := acquireUnmarshalOptions(opts)
+	defer options.release()
+	typ, err := options.registry().lookup(reflected.Type())
+	if err != nil {
+		return err
+	}
+	return unmarshalValue(bytes, typ, reflected, options)
+}
 
-	typ := CaptureType(reflected.Type())
+func unmarshalValue(bytes []byte, typ *Type, reflected reflect.Value, options *UnmarshalOptions) error {
+	if options.Reset {
+		reflected.SetZero()
+	}
+	if err := unmarshalType(bytes, typ, reflected, options); err != nil {
+		return err
+	}
+	if !options.AllowPartial {
+		return checkRequired(typ, reflected)
+	}
+	return nil
+}
+
+func unmarshal(bytes []byte, reflected reflect.Value, options *UnmarshalOptions) error {
+	typ, err := options.registry().lookup(reflected.Type())
+	if err != nil {
+		return err
+	}
+	return unmarshalType(bytes, typ, reflected, options)
+}
+
+func unmarshalType(bytes []byte, typ *Type, reflected reflect.Value, options *UnmarshalOptions) error {
+	base := typ.base(reflected)
+	if base != nil && typ.direct.Load() {
+		return unmarshalDirect(bytes, typ, base, options)
+	}
+	if err := options.enter(); err != nil {
+		return err
+	}
+	defer options.leave()
+	var arrays map[*Field]reflect.Value
 	pos := 0
 	for pos < len(bytes) {
-		fieldNum, _, consumed, err := decodeTag(bytes, pos)
+		fieldNum, wireType, consumed, err := decodeTag(bytes, pos)
 		if err != nil {
 			return err
 		}
+		start := pos
 		pos += consumed
-		field, ok := typ.FieldsIndexer[int(fieldNum)]
+		field, ok := typ.field(int(fieldNum))
 		if !ok {
+			consumed, err := skipValue(bytes, pos, fieldNum, wireType, options)
+			if err != nil {
+				return err
+			}
+			pos += consumed
+			if typ.UnknownFields != nil && !options.DiscardUnknown {
+				unknown := reflected.FieldByIndex(typ.UnknownFields)
+				unknown.SetBytes(append(unknown.Bytes(), bytes[start:pos]...))
+			}
+			continue
+		}
+		if codec := options.compiled(field, base); codec != nil {
+			pos, err = codec.decode(bytes, pos, wireType, unsafe.Add(base, codec.offset), options)
+			if err != nil {
+				return err
+			}
 			continue
 		}
+		var opts []codecOption
+		if field.Kind == reflect.Map {
+			opts = append(opts, withMapInfo(field.Tags.mapKeyInfo(), field.Tags.mapValueInfo()))
+		}
 		v2 := reflected.FieldByIndex(field.FieldIndex)
-		consumed, err = decodeValue(&v2, field.Kind, bytes, field.Tags.Protobuf.WireType, pos)
+		if len(field.OneOf) != 0 {
+			v2 = field.setOneOfCase(v2)
+		}
+		if field.Kind == reflect.Array && field.IsPointer {
+			elem, _ := dereference(&v2)
+			v2 = *elem
+		}
+		if field.Kind == reflect.Array && field.Index != reflect.Uint8 {
+			if arrays == nil {
+				arrays = make(map[*Field]reflect.Value)
+			}
+			items, ok := arrays[field]
+			if !ok {
+				items = reflect.New(reflect.SliceOf(v2.Type().Elem())).Elem()
+			}
+			consumed, err = decodeValue(&items, reflect.Slice, bytes, wireType, field.Tags.Protobuf, pos, options)
+			if err != nil {
+				return err
+			}
+			arrays[field] = items
+			pos = consumed
+			continue
+		}
+		consumed, err = decodeValue(&v2, field.Kind, bytes, wireType, field.Tags.Protobuf, pos, options, opts...)
 		if err != nil {
 			return err
 		}
 		pos = consumed
 	}
+	if arrays == nil {
+		return nil
+	}
+	for field, items := range arrays {
+		if err := setArray(reflect.Indirect(reflected.FieldByIndex(field.FieldIndex)), items); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
 	return nil
 }
 
-func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType WireType, pos int) (int, error) {
+func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, onWire WireType, info *ProtobufInfo, pos int, options *UnmarshalOptions, opts ...codecOption) (int, error) {
+	wireType := info.WireType
+	if info.Wrapper {
+		return decodeWrapper(v, kind, bytes, pos, options)
+	}
+	if wireType == WireTypeLen {
+		t := v.Type()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if conv := converterFor(t); conv != nil {
+			return decodeConverted(v, conv, bytes, pos)
+		}
+	}
 	switch kind {
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
 		{
 			elem, _ := dereference(v)
+			if wireType == WireTypeLen && elem.Type() == durationType {
+				value, consumed, err := decodeDuration(bytes, pos, options)
+				if err != nil {
+					return pos, err
+				}
+				elem.SetInt(int64(value))
+				return pos + consumed, nil
+			}
 			if wireType == WireTypeI32 {
 				value, consumed, err := decodeFixed32(bytes, pos)
 				if err != nil {
@@ -253,6 +463,14 @@ func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType Wir
 				elem.SetInt(value)
 				return pos + consumed, nil
 			}
+			if info.ZigZag {
+				value, consumed, err := decodeZigZag(bytes, pos)
+				if err != nil {
+					return pos, err
+				}
+				elem.SetInt(value)
+				return pos + consumed, nil
+			}
 			value, consumed, err := decodeVarint(bytes, pos)
 			if err != nil {
 				return pos, err
@@ -319,7 +537,7 @@ func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType Wir
 	case reflect.String:
 		{
 			elem, _ := dereference(v)
-			value, consumed, err := decodeString(bytes, pos)
+			value, consumed, err := options.decodeString(bytes, pos)
 			if err != nil {
 				return pos, err
 			}
@@ -329,53 +547,91 @@ func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType Wir
 	case reflect.Array, reflect.Slice:
 		{
 			k := v.Type().Elem().Kind()
+			if info.isListValue(k) {
+				value, consumed, err := viewBytes(bytes, pos)
+				if err != nil {
+					return pos, err
+				}
+				list, err := decodeListValue(value, options)
+				if err != nil {
+					return pos, err
+				}
+				if v.IsZero() {
+					v.Set(reflect.MakeSlice(v.Type(), 0, len(list)))
+				}
+				for _, item := range list {
+					v.Set(reflect.Append(*v, structValueOf(item, v.Type().Elem())))
+				}
+				return pos + consumed, nil
+			}
 			if k == reflect.Uint8 {
-				value, consumed, err := decodeBytes(bytes, pos)
+				value, consumed, err := options.decodeBytes(bytes, pos)
 				if err != nil {
 					return pos, err
 				}
+				if v.Kind() == reflect.Array {
+					return pos + consumed, setArray(*v, reflect.ValueOf(value))
+				}
 				v.SetBytes(value)
 				return pos + consumed, nil
 			}
+			if v.Kind() == reflect.Array {
+				return pos, fmt.Errorf("unexpected nested array %v", v.Type())
+			}
 			if v.IsZero() {
 				v.Set(reflect.MakeSlice(v.Type(), 0, 0))
 			}
 			tmp := reflect.New(v.Type().Elem())
 			tmp = tmp.Elem()
-			switch wireType {
-			case WireTypeVarint, WireTypeI32, WireTypeI64:
-				{
-					value, consumed, err := decodeBytes(bytes, pos)
-					if err != nil {
-						return pos, err
-					}
-					innerPos := 0
-					for innerPos < len(value) {
-						elem, addr := dereference(&tmp)
-						consumed, err := decodeValue(elem, elem.Kind(), value, wireType, innerPos)
-						if err != nil {
-							return pos, err
-						}
-						innerPos = consumed
-						v.Set(reflect.Append(*v, *addr))
-					}
-					return pos + consumed, nil
+			if onWire == WireTypeLen && info.isScalar() {
+				value, consumed, err := viewBytes(bytes, pos)
+				if err != nil {
+					return pos, err
 				}
-			default:
-				{
+				innerPos := 0
+				for innerPos < len(value) {
 					elem, addr := dereference(&tmp)
-					consumed, err := decodeValue(elem, elem.Kind(), bytes, wireType, pos)
+					consumed, err := decodeValue(elem, elem.Kind(), value, wireType, info, innerPos, options)
 					if err != nil {
 						return pos, err
 					}
+					innerPos = consumed
 					v.Set(reflect.Append(*v, *addr))
-					return consumed, nil
 				}
+				return pos + consumed, nil
+			}
+			elem, addr := dereference(&tmp)
+			consumed, err := decodeValue(elem, elem.Kind(), bytes, onWire, info.elementInfo(elementKinds(elem.Type())), pos, options)
+			if err != nil {
+				return pos, err
 			}
+			v.Set(reflect.Append(*v, *addr))
+			return consumed, nil
 		}
 	case reflect.Map:
 		{
-			value, c, err := decodeBytes(bytes, pos)
+			if info.isStruct(v.Type().Key().Kind(), v.Type().Elem().Kind()) {
+				value, consumed, err := viewBytes(bytes, pos)
+				if err != nil {
+					return pos, err
+				}
+				fields, err := decodeStruct(value, options)
+				if err != nil {
+					return pos, err
+				}
+				if v.IsZero() {
+					v.Set(reflect.MakeMapWithSize(v.Type(), len(fields)))
+				}
+				for key, item := range fields {
+					v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), structValueOf(item, v.Type().Elem()))
+				}
+				return pos + consumed, nil
+			}
+			codecOptions := new(codecOptions)
+			for _, opt := range opts {
+				opt(codecOptions)
+			}
+			value, c, err := viewBytes(bytes, pos)
 			if err != nil {
 				return pos, err
 			}
@@ -384,29 +640,34 @@ func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType Wir
 			if v.IsZero() {
 				v.Set(reflect.MakeMap(reflect.MapOf(keyType, valueType)))
 			}
-			innerPos := 0
-			_, keyWireType, consumed, err := decodeTag(value, innerPos)
-			if err != nil {
-				return pos, err
-			}
-			innerPos += consumed
 			key := reflect.New(keyType).Elem()
-			consumed, err = decodeValue(&key, key.Kind(), value, keyWireType, innerPos)
-			if err != nil {
-				return pos, err
-			}
-			innerPos = consumed
-
-			_, valueWireType, consumed, err := decodeTag(value, innerPos)
-			if err != nil {
-				return pos, err
-			}
-			innerPos += consumed
 			val := reflect.New(valueType).Elem()
 			elem, addr := dereference(&val)
-			_, err = decodeValue(elem, elem.Kind(), value, valueWireType, innerPos)
-			if err != nil {
-				return pos, err
+			innerPos := 0
+			for innerPos < len(value) {
+				fieldNum, wireType, consumed, err := decodeTag(value, innerPos)
+				if err != nil {
+					return pos, err
+				}
+				innerPos += consumed
+				switch fieldNum {
+				case 1:
+					{
+						innerPos, err = decodeValue(&key, key.Kind(), value, wireType, codecOptions.MapKeyInfo, innerPos, options)
+					}
+				case 2:
+					{
+						innerPos, err = decodeValue(elem, elem.Kind(), value, wireType, codecOptions.MapValueInfo, innerPos, options)
+					}
+				default:
+					{
+						consumed, err = skipValue(value, innerPos, fieldNum, wireType, options)
+						innerPos += consumed
+					}
+				}
+				if err != nil {
+					return pos, err
+				}
 			}
 			v.SetMapIndex(key, *addr)
 			return pos + c, nil
@@ -414,15 +675,46 @@ func decodeValue(v *reflect.Value, kind reflect.Kind, bytes []byte, wireType Wir
 	case reflect.Struct:
 		{
 			elem, _ := dereference(v)
-			value, c, err := decodeBytes(bytes, pos)
+			if elem.Type() == timeType {
+				value, consumed, err := decodeTimestamp(bytes, pos, options)
+				if err != nil {
+					return pos, err
+				}
+				elem.Set(reflect.ValueOf(value))
+				return pos + consumed, nil
+			}
+			if wireType == WireTypeSGroup {
+				value, c, err := decodeGroup(bytes, pos, int32(info.FieldNum), options)
+				if err != nil {
+					return pos, err
+				}
+				if err := unmarshal(value, *elem, options); err != nil {
+					return pos, err
+				}
+				return pos + c, nil
+			}
+			value, c, err := viewBytes(bytes, pos)
 			if err != nil {
 				return pos, err
 			}
-			if err := Unmarshal(value, elem.Addr().Interface()); err != nil {
+			if err := unmarshal(value, *elem, options); err != nil {
 				return c, err
 			}
 			return pos + c, nil
 		}
+	case reflect.Interface:
+		{
+			value, consumed, err := viewBytes(bytes, pos)
+			if err != nil {
+				return pos, err
+			}
+			item, err := decodeStructValue(value, options)
+			if err != nil {
+				return pos, err
+			}
+			v.Set(structValueOf(item, v.Type()))
+			return pos + consumed, nil
+		}
 	}
 	return pos, fmt.Errorf("unexpected type %v", kind)
 }
@@ -0,0 +1,172 @@
+package protolizer
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	ProtoMarshaler interface {
+		MarshalProto() ([]byte, error)
+	}
+	ProtoUnmarshaler interface {
+		UnmarshalProto([]byte) error
+	}
+	converter struct {
+		marshal   func(reflect.Value) ([]byte, error)
+		unmarshal func(reflect.Value, []byte) error
+	}
+)
+
+var (
+	_converters sync.Map
+)
+
+func RegisterConverter[T any](marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error)) {
+	t := reflect.TypeFor[T]()
+	_converters.Store(t, &converter{
+		marshal: func(v reflect.Value) ([]byte, error) {
+			return marshal(v.Interface().(T))
+		},
+		unmarshal: func(v reflect.Value, data []byte) error {
+			value, err := unmarshal(data)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(&value).Elem())
+			return nil
+		},
+	})
+	if t.Kind() == reflect.Pointer {
+		_converters.Store(t.Elem(), &converter{
+			marshal: func(v reflect.Value) ([]byte, error) {
+				return marshal(addressable(v).Interface().(T))
+			},
+			unmarshal: func(v reflect.Value, data []byte) error {
+				value, err := unmarshal(data)
+				if err != nil {
+					return err
+				}
+				reflected := reflect.ValueOf(value)
+				if reflected.IsNil() {
+					return fmt.Errorf("converter for %v returned nil", t)
+				}
+				v.Set(reflected.Elem())
+				return nil
+			},
+		})
+	}
+	_generation.Add(1)
+}
+
+func converterFor(t reflect.Type) *converter {
+	if cached, ok := _converters.Load(t); ok {
+		return cached.(*converter)
+	}
+	out := newConverter(t)
+	_converters.Store(t, out)
+	return out
+}
+
+func newConverter(t reflect.Type) *converter {
+	if isWellKnown(t) || t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return nil
+	}
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(reflect.TypeFor[ProtoMarshaler]()) && ptr.Implements(reflect.TypeFor[ProtoUnmarshaler]()) {
+		return &converter{
+			marshal: func(v reflect.Value) ([]byte, error) {
+				return addressable(v).Interface().(ProtoMarshaler).MarshalProto()
+			},
+			unmarshal: func(v reflect.Value, data []byte) error {
+				return v.Addr().Interface().(ProtoUnmarshaler).UnmarshalProto(data)
+			},
+		}
+	}
+	if isMessage(t) {
+		return nil
+	}
+	if ptr.Implements(reflect.TypeFor[encoding.BinaryMarshaler]()) && ptr.Implements(reflect.TypeFor[encoding.BinaryUnmarshaler]()) {
+		return &converter{
+			marshal: func(v reflect.Value) ([]byte, error) {
+				return addressable(v).Interface().(encoding.BinaryMarshaler).MarshalBinary()
+			},
+			unmarshal: func(v reflect.Value, data []byte) error {
+				return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+			},
+		}
+	}
+	if ptr.Implements(reflect.TypeFor[encoding.TextMarshaler]()) && ptr.Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return &converter{
+			marshal: func(v reflect.Value) ([]byte, error) {
+				return addressable(v).Interface().(encoding.TextMarshaler).MarshalText()
+			},
+			unmarshal: func(v reflect.Value, data []byte) error {
+				return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(data)
+			},
+		}
+	}
+	return nil
+}
+
+func isMessage(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range t.NumField() {
+		if _, ok := t.Field(i).Tag.Lookup("protobuf"); ok {
+			return true
+		}
+		if _, ok := t.Field(i).Tag.Lookup("protobuf_oneof"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	out := reflect.New(v.Type())
+	out.Elem().Set(v)
+	return out
+}
+
+func isConverted(t reflect.Type, info *ProtobufInfo) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return info != nil && info.WireType == WireTypeLen && converterFor(t) != nil
+}
+
+func encodeConverted(out []byte, v *reflect.Value, conv *converter, options *MarshalOptions) ([]byte, error) {
+	data, err := options.converted(*v, conv)
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(out, data), nil
+}
+
+func sizeConverted(v reflect.Value, conv *converter, options *MarshalOptions) (int, error) {
+	data, err := conv.marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	options.cache.pushData(data)
+	return sizeBytes(len(data)), nil
+}
+
+func decodeConverted(v *reflect.Value, conv *converter, bytes []byte, pos int) (int, error) {
+	value, consumed, err := decodeBytes(bytes, pos)
+	if err != nil {
+		return pos, err
+	}
+	elem, _ := dereference(v)
+	if err := conv.unmarshal(*elem, value); err != nil {
+		return pos, err
+	}
+	return pos + consumed, nil
+}
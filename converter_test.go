@@ -0,0 +1,83 @@
+package protolizer
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type convertedMessage struct {
+	Amount  *big.Int            `protobuf:"bytes,1,opt,name=amount,proto3"`
+	Amounts []*big.Int          `protobuf:"bytes,2,rep,name=amounts,proto3"`
+	Ledger  map[string]*big.Int `protobuf:"bytes,3,rep,name=ledger,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func init() {
+	RegisterConverter(
+		func(v *big.Int) ([]byte, error) { return v.Bytes(), nil },
+		func(b []byte) (*big.Int, error) { return new(big.Int).SetBytes(b), nil },
+	)
+	RegisterTypeFor[convertedMessage]()
+}
+
+func TestConverterGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *convertedMessage
+		want  string
+		read  map[string]any
+	}{
+		{"field", &convertedMessage{Amount: big.NewInt(258)}, "0a020102", map[string]any{"Amount": []byte{1, 2}}},
+		{"slice element", &convertedMessage{Amounts: []*big.Int{big.NewInt(1), big.NewInt(0)}}, "1201011200", map[string]any{"Amounts": []any{[]byte{1}, []byte{}}}},
+		{"map value", &convertedMessage{Ledger: map[string]*big.Int{"a": big.NewInt(5)}}, "1a060a0161120105", map[string]any{"Ledger": map[string]any{"a": []byte{5}}}},
+	}
+	name := TypeName(reflect.TypeFor[convertedMessage]())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			got := new(convertedMessage)
+			if err := Unmarshal(want, got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(formatConverted(got), formatConverted(tt.value)) {
+				t.Fatalf("Unmarshal = %v, want %v", formatConverted(got), formatConverted(tt.value))
+			}
+			assertReadWrite[convertedMessage](t, want)
+			read, err := Read(name, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(read, PresentFieldsKey)
+			if !reflect.DeepEqual(read, tt.read) {
+				t.Fatalf("Read = %#v, want %#v", read, tt.read)
+			}
+		})
+	}
+}
+
+func TestConverterRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterType(reflect.TypeFor[convertedMessage]())
+	data, err := r.Marshal(&convertedMessage{Amount: big.NewInt(258)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decodeHex(t, "0a020102"); !reflect.DeepEqual(data, want) {
+		t.Fatalf("Marshal = %x, want %x", data, want)
+	}
+}
+
+func formatConverted(v *convertedMessage) []string {
+	var out []string
+	if v.Amount != nil {
+		out = append(out, "amount="+v.Amount.String())
+	}
+	for _, amount := range v.Amounts {
+		out = append(out, "amounts="+amount.String())
+	}
+	for key, amount := range v.Ledger {
+		out = append(out, "ledger."+key+"="+amount.String())
+	}
+	return out
+}
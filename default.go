@@ -0,0 +1,307 @@
+package protolizer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func Get(v any, name string) (any, error) {
+	reflected := reflect.ValueOf(v)
+	if reflected.Kind() == reflect.Pointer {
+		reflected = reflected.Elem()
+	}
+	if reflected.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct but got %T", v)
+	}
+	typ := CaptureType(reflected.Type())
+	if typ == nil {
+		return nil, fmt.Errorf("type %s is not registered", TypeName(reflected.Type()))
+	}
+	for _, field := range typ.Fields {
+		if field.Name != name {
+			continue
+		}
+		value := reflected.FieldByIndex(field.FieldIndex)
+		t := value.Type()
+		if len(field.OneOf) != 0 {
+			value = field.oneOfCase(value)
+			t = field.wrapper.Elem().Field(0).Type
+		}
+		if field.isPresent(value) {
+			if value.Kind() == reflect.Pointer {
+				value = value.Elem()
+			}
+			return value.Interface(), nil
+		}
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		return field.defaultValue(t)
+	}
+	return nil, fmt.Errorf("field %s not found in %s", name, typ.Name)
+}
+
+func (f *Field) hasDefault() bool {
+	return len(f.Tags.Protobuf.Default) != 0
+}
+
+func (f *Field) defaultValue(t reflect.Type) (any, error) {
+	out := reflect.New(t).Elem()
+	if !f.hasDefault() {
+		return out.Interface(), nil
+	}
+	value, err := parseDefault(f, f.Tags.Protobuf.Default)
+	if err != nil {
+		return nil, err
+	}
+	switch value := value.(type) {
+	case int64:
+		{
+			if out.OverflowInt(value) {
+				return nil, fmt.Errorf("default %d overflows %v", value, t)
+			}
+			out.SetInt(value)
+		}
+	case uint64:
+		{
+			if out.OverflowUint(value) {
+				return nil, fmt.Errorf("default %d overflows %v", value, t)
+			}
+			out.SetUint(value)
+		}
+	case float64:
+		{
+			out.SetFloat(value)
+		}
+	case bool:
+		{
+			out.SetBool(value)
+		}
+	case string:
+		{
+			out.SetString(value)
+		}
+	case []byte:
+		{
+			if out.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("default %q cannot be assigned to %v", f.Tags.Protobuf.Default, t)
+			}
+			out.SetBytes(value)
+		}
+	}
+	return out.Interface(), nil
+}
+
+func (f *Field) defaultAnonymous() (any, error) {
+	value, err := parseDefault(f, f.Tags.Protobuf.Default)
+	if err != nil {
+		return nil, err
+	}
+	switch value := value.(type) {
+	case int64:
+		{
+			return anonymousInteger(f, value), nil
+		}
+	case uint64:
+		{
+			return anonymousUnsigned(f, value), nil
+		}
+	}
+	return value, nil
+}
+
+func fillDefaults(typ *Type, out map[string]any) error {
+	for _, field := range typ.Fields {
+		if _, ok := out[field.Name]; ok || len(field.OneOf) != 0 || !field.hasDefault() {
+			continue
+		}
+		value, err := field.defaultAnonymous()
+		if err != nil {
+			return err
+		}
+		out[field.Name] = value
+		if _, ok := out[PresentFieldsKey]; !ok {
+			out[PresentFieldsKey] = []string{}
+		}
+	}
+	return nil
+}
+
+func isFilledDefault(field *Field, v map[string]any) bool {
+	present, ok := v[PresentFieldsKey].([]string)
+	if !ok || !field.hasDefault() || slices.Contains(present, field.Name) {
+		return false
+	}
+	return field.isDefault(v[field.Name])
+}
+
+func (f *Field) isDefault(value any) bool {
+	def, err := f.defaultAnonymous()
+	if err != nil {
+		return false
+	}
+	switch value := value.(type) {
+	case float64:
+		{
+			def, ok := def.(float64)
+			return ok && (value == def || math.IsNaN(value) && math.IsNaN(def))
+		}
+	case []byte:
+		{
+			def, ok := def.([]byte)
+			return ok && slices.Equal(value, def)
+		}
+	}
+	return value == def
+}
+
+func parseDefault(f *Field, def string) (any, error) {
+	switch f.Kind {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			value, err := strconv.ParseInt(def, 10, 64)
+			if err != nil {
+				if enum := f.enum(); enum != nil {
+					if number, ok := enum.Numbers[def]; ok {
+						return int64(number), nil
+					}
+				}
+				return nil, fmt.Errorf("invalid default %q for field %s: %w", def, f.Name, err)
+			}
+			return value, nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			value, err := strconv.ParseUint(def, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default %q for field %s: %w", def, f.Name, err)
+			}
+			return value, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		{
+			switch def {
+			case "inf":
+				{
+					return math.Inf(1), nil
+				}
+			case "-inf":
+				{
+					return math.Inf(-1), nil
+				}
+			case "nan":
+				{
+					return math.NaN(), nil
+				}
+			}
+			value, err := strconv.ParseFloat(def, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default %q for field %s: %w", def, f.Name, err)
+			}
+			return value, nil
+		}
+	case reflect.Bool:
+		{
+			switch def {
+			case "1", "true":
+				{
+					return true, nil
+				}
+			case "0", "false":
+				{
+					return false, nil
+				}
+			}
+		}
+	case reflect.String:
+		{
+			return def, nil
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			if f.Index == reflect.Uint8 {
+				return unescapeDefault(def)
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid default %q for field %s", def, f.Name)
+}
+
+func unescapeDefault(def string) ([]byte, error) {
+	out := make([]byte, 0, len(def))
+	for i := 0; i < len(def); i++ {
+		if def[i] != '\\' {
+			out = append(out, def[i])
+			continue
+		}
+		i++
+		if i == len(def) {
+			return nil, fmt.Errorf("invalid escape at the end of %q", def)
+		}
+		switch c := def[i]; c {
+		case '"', '\'', '\\', '?':
+			{
+				out = append(out, c)
+			}
+		case 'a':
+			{
+				out = append(out, '\a')
+			}
+		case 'b':
+			{
+				out = append(out, '\b')
+			}
+		case 'f':
+			{
+				out = append(out, '\f')
+			}
+		case 'n':
+			{
+				out = append(out, '\n')
+			}
+		case 'r':
+			{
+				out = append(out, '\r')
+			}
+		case 't':
+			{
+				out = append(out, '\t')
+			}
+		case 'v':
+			{
+				out = append(out, '\v')
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			{
+				n := len(def[i:]) - len(strings.TrimLeft(def[i:], "01234567"))
+				n = min(n, 3)
+				value, err := strconv.ParseUint(def[i:i+n], 8, 8)
+				if err != nil {
+					return nil, fmt.Errorf("invalid octal escape in %q: %w", def, err)
+				}
+				out = append(out, byte(value))
+				i += n - 1
+			}
+		case 'x':
+			{
+				n := len(def[i+1:]) - len(strings.TrimLeft(def[i+1:], "0123456789abcdefABCDEF"))
+				n = min(n, 2)
+				value, err := strconv.ParseUint(def[i+1:i+1+n], 16, 8)
+				if err != nil {
+					return nil, fmt.Errorf("invalid hex escape in %q: %w", def, err)
+				}
+				out = append(out, byte(value))
+				i += n
+			}
+		default:
+			{
+				return nil, fmt.Errorf("invalid escape \\%c in %q", c, def)
+			}
+		}
+	}
+	return out, nil
+}
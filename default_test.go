@@ -0,0 +1,165 @@
+package protolizer
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type (
+	defaultLevel   int32
+	defaultMessage struct {
+		I32    *int32                  `protobuf:"varint,1,opt,name=i32,def=-5"`
+		I64    *int64                  `protobuf:"varint,2,opt,name=i64,def=-1099511627776"`
+		U32    *uint32                 `protobuf:"varint,3,opt,name=u32,def=7"`
+		U64    *uint64                 `protobuf:"varint,4,opt,name=u64,def=8"`
+		S32    *int32                  `protobuf:"zigzag32,5,opt,name=s32,def=-3"`
+		F32    *float32                `protobuf:"fixed32,6,opt,name=f32,def=1.5"`
+		F64    *float64                `protobuf:"fixed64,7,opt,name=f64,def=-inf"`
+		Bool   *bool                   `protobuf:"varint,8,opt,name=bool,def=1"`
+		Str    *string                 `protobuf:"bytes,9,opt,name=str,def=a,b"`
+		Bytes  []byte                  `protobuf:"bytes,10,opt,name=bytes,def=\\001x\\\\"`
+		Enum   *defaultLevel           `protobuf:"varint,11,opt,name=enum,enum=defaultLevel,def=2"`
+		Named  *defaultLevel           `protobuf:"varint,12,opt,name=named,enum=defaultLevel,def=LOW"`
+		Plain  int32                   `protobuf:"varint,13,opt,name=plain,def=10"`
+		Choice isDefaultMessage_Choice `protobuf_oneof:"choice"`
+	}
+	isDefaultMessage_Choice interface {
+		isDefaultMessage_Choice()
+	}
+	defaultMessage_Text struct {
+		Text string `protobuf:"bytes,14,opt,name=text,oneof,def=none"`
+	}
+	defaultMessage_Count struct {
+		Count int32 `protobuf:"varint,15,opt,name=count,oneof,def=3"`
+	}
+)
+
+func (*defaultMessage_Text) isDefaultMessage_Choice()  {}
+func (*defaultMessage_Count) isDefaultMessage_Choice() {}
+
+func (*defaultMessage) XXX_OneofWrappers() []any {
+	return []any{
+		(*defaultMessage_Text)(nil),
+		(*defaultMessage_Count)(nil),
+	}
+}
+
+func init() {
+	RegisterEnum[defaultLevel](map[int32]string{0: "NONE", 1: "LOW", 2: "HIGH"})
+	RegisterTypeFor[defaultMessage]()
+}
+
+func TestDefaultGet(t *testing.T) {
+	tests := []struct {
+		field string
+		want  any
+	}{
+		{"I32", int32(-5)},
+		{"I64", int64(-1 << 40)},
+		{"U32", uint32(7)},
+		{"U64", uint64(8)},
+		{"S32", int32(-3)},
+		{"F32", float32(1.5)},
+		{"F64", math.Inf(-1)},
+		{"Bool", true},
+		{"Str", "a,b"},
+		{"Bytes", []byte("\x01x\\")},
+		{"Enum", defaultLevel(2)},
+		{"Named", defaultLevel(1)},
+		{"Plain", int32(0)},
+		{"Text", "none"},
+		{"Count", int32(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := Get(&defaultMessage{}, tt.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Get = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultGetSet(t *testing.T) {
+	zero, empty, no := int32(0), "", false
+	value := &defaultMessage{I32: &zero, Str: &empty, Bool: &no, Bytes: []byte{}, Plain: 4, Choice: &defaultMessage_Count{}}
+	tests := []struct {
+		field string
+		want  any
+	}{
+		{"I32", int32(0)},
+		{"Str", ""},
+		{"Bool", false},
+		{"Bytes", []byte{}},
+		{"Plain", int32(4)},
+		{"Count", int32(0)},
+		{"Text", "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := Get(value, tt.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Get = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+	if _, err := Get(value, "Missing"); err == nil {
+		t.Fatal("expected an error for a missing field")
+	}
+}
+
+func TestDefaultRead(t *testing.T) {
+	name := TypeName(reflect.TypeFor[defaultMessage]())
+	got, err := Read(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		PresentFieldsKey: []string{},
+		"I32":            float64(-5),
+		"I64":            float64(-1 << 40),
+		"U32":            float64(7),
+		"U64":            float64(8),
+		"S32":            float64(-3),
+		"F32":            float64(1.5),
+		"F64":            math.Inf(-1),
+		"Bool":           true,
+		"Str":            "a,b",
+		"Bytes":          []byte("\x01x\\"),
+		"Enum":           "HIGH",
+		"Named":          "LOW",
+		"Plain":          float64(10),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Read = %#v, want %#v", got, want)
+	}
+	data, err := Write(name, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("Write of filled defaults = %x", data)
+	}
+	got["I32"] = float64(1)
+	got[PresentFieldsKey] = []string{"Str"}
+	data, err = Write(name, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decodeHex(t, "08014a03612c62"); !bytes.Equal(data, want) {
+		t.Fatalf("Write = %x, want %x", data, want)
+	}
+}
+
+func TestDefaultMarshal(t *testing.T) {
+	assertMarshal(t, &defaultMessage{}, decodeHex(t, "6800"))
+	assertMarshal(t, &defaultMessage{Plain: 10, Choice: &defaultMessage_Text{}}, decodeHex(t, "680a7200"))
+}
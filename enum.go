@@ -0,0 +1,65 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func RegisterEnum[T any](values map[int32]string) {
+	_default.RegisterEnum(reflect.TypeFor[T](), values)
+}
+
+func newEnum(t reflect.Type, values map[int32]string) *Enum {
+	if !isInteger(t.Kind()) {
+		panic(fmt.Errorf("invalid enum %v: expected an integer type", t))
+	}
+
+	out := new(Enum)
+	out.Name = TypeName(t)
+	out.Values = make(map[int32]string)
+	out.Numbers = make(map[string]int32)
+	for number, name := range values {
+		out.Values[number] = name
+		out.Numbers[name] = number
+	}
+	return out
+}
+
+func CaptureEnumFor[T any]() *Enum {
+	return _default.CaptureEnumByName(TypeName(reflect.TypeFor[T]()))
+}
+
+func CaptureEnumByName(typeName string) *Enum {
+	return _default.CaptureEnumByName(typeName)
+}
+
+func (f *Field) enum() *Enum {
+	return registryOf(f.registry).CaptureEnumByName(f.Enum)
+}
+
+func enumTypeName(registry *Registry, t reflect.Type, info *ProtobufInfo) string {
+	if !isInteger(t.Kind()) {
+		return ""
+	}
+	if info != nil && info.Enum {
+		return TypeName(t)
+	}
+	if registry.CaptureEnumByName(TypeName(t)) != nil {
+		return TypeName(t)
+	}
+	return ""
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			return true
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			return true
+		}
+	}
+	return false
+}
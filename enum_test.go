@@ -0,0 +1,72 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type enumMessage struct {
+	Color  mapColor   `protobuf:"varint,1,opt,name=color,proto3,enum=mapColor"`
+	Colors []mapColor `protobuf:"varint,2,rep,packed,name=colors,proto3,enum=mapColor"`
+}
+
+func init() {
+	RegisterTypeFor[enumMessage]()
+}
+
+func TestEnumGolden(t *testing.T) {
+	name := TypeName(reflect.TypeFor[enumMessage]())
+	tests := []struct {
+		name  string
+		value *enumMessage
+		want  string
+		read  map[string]any
+	}{
+		{"zero", &enumMessage{}, "", map[string]any{}},
+		{"scalar", &enumMessage{Color: 2}, "0802", map[string]any{"Color": "GREEN"}},
+		{"packed", &enumMessage{Colors: []mapColor{1, 0, 2}}, "1203010002", map[string]any{"Colors": []any{"RED", "NONE", "GREEN"}}},
+		{"unknown", &enumMessage{Color: 7, Colors: []mapColor{1, 9}}, "080712020109", map[string]any{"Color": float64(7), "Colors": []any{"RED", float64(9)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[enumMessage](t, want)
+			got, err := Read(name, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.read) {
+				t.Fatalf("Read = %#v, want %#v", got, tt.read)
+			}
+		})
+	}
+}
+
+func TestEnumWrite(t *testing.T) {
+	name := TypeName(reflect.TypeFor[enumMessage]())
+	tests := []struct {
+		name  string
+		value map[string]any
+		want  string
+	}{
+		{"names", map[string]any{"Color": "GREEN", "Colors": []any{"RED", "NONE"}}, "080212020100"},
+		{"numbers", map[string]any{"Color": 2, "Colors": []any{1, float64(9)}}, "080212020109"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Write(name, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Fatalf("Write = %x, want %x", got, want)
+			}
+		})
+	}
+	if _, err := Write(name, map[string]any{"Color": "BLUE"}); err == nil {
+		t.Fatal("expected an error for an unknown enum name")
+	}
+}
@@ -6,15 +6,19 @@ import (
 )
 
 func encodeFixed32(value int32) []byte {
-	buf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(buf, uint32(value))
-	return buf
+	return appendFixed32(make([]byte, 0, 4), value)
 }
 
 func encodeFixed64(value int64) []byte {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, uint64(value))
-	return buf
+	return appendFixed64(make([]byte, 0, 8), value)
+}
+
+func appendFixed32(out []byte, value int32) []byte {
+	return binary.LittleEndian.AppendUint32(out, uint32(value))
+}
+
+func appendFixed64(out []byte, value int64) []byte {
+	return binary.LittleEndian.AppendUint64(out, uint64(value))
 }
 
 func decodeFixed32(data []byte, offset int) (int32, int, error) {
@@ -7,17 +7,19 @@ import (
 )
 
 func encodeFloat32(value float32) []byte {
-	buf := make([]byte, 4)
-	bits := math.Float32bits(value)
-	binary.LittleEndian.PutUint32(buf, bits)
-	return buf
+	return appendFloat32(make([]byte, 0, 4), value)
 }
 
 func encodeFloat64(value float64) []byte {
-	buf := make([]byte, 8)
-	bits := math.Float64bits(value)
-	binary.LittleEndian.PutUint64(buf, bits)
-	return buf
+	return appendFloat64(make([]byte, 0, 8), value)
+}
+
+func appendFloat32(out []byte, value float32) []byte {
+	return binary.LittleEndian.AppendUint32(out, math.Float32bits(value))
+}
+
+func appendFloat64(out []byte, value float64) []byte {
+	return binary.LittleEndian.AppendUint64(out, math.Float64bits(value))
 }
 
 func decodeFloat32(data []byte, offset int) (float32, int, error) {
@@ -0,0 +1,38 @@
+package protolizer
+
+import "fmt"
+
+func encodeGroup(fieldNumber int32, value []byte) ([]byte, error) {
+	tag, err := encodeTag(fieldNumber, WireTypeEGroup)
+	if err != nil {
+		return nil, err
+	}
+	return append(value, tag...), nil
+}
+
+func decodeGroup(data []byte, offset int, fieldNumber int32, options *UnmarshalOptions) ([]byte, int, error) {
+	if err := options.enter(); err != nil {
+		return nil, 0, err
+	}
+	defer options.leave()
+	pos := offset
+	for pos < len(data) {
+		num, wireType, consumed, err := decodeTag(data, pos)
+		if err != nil {
+			return nil, 0, err
+		}
+		if wireType == WireTypeEGroup {
+			if num != fieldNumber {
+				return nil, 0, fmt.Errorf("mismatched end group: expected field %d but got %d", fieldNumber, num)
+			}
+			return data[offset:pos], pos + consumed - offset, nil
+		}
+		pos += consumed
+		consumed, err = skipValue(data, pos, num, wireType, options)
+		if err != nil {
+			return nil, 0, err
+		}
+		pos += consumed
+	}
+	return nil, 0, fmt.Errorf("unterminated group for field %d", fieldNumber)
+}
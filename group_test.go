@@ -0,0 +1,88 @@
+package protolizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type (
+	groupInner struct {
+		B string `protobuf:"bytes,4,opt,name=b"`
+	}
+	groupItem struct {
+		A     int32       `protobuf:"varint,2,opt,name=a"`
+		Inner *groupInner `protobuf:"group,3,opt,name=Inner"`
+	}
+	groupMessage struct {
+		Single   *groupItem   `protobuf:"group,1,opt,name=Single"`
+		Repeated []*groupItem `protobuf:"group,5,rep,name=Repeated"`
+	}
+	groupUnknown struct {
+		B       string `protobuf:"bytes,4,opt,name=b"`
+		Unknown UnknownFields
+	}
+)
+
+func init() {
+	RegisterTypeFor[groupInner]()
+	RegisterTypeFor[groupItem]()
+	RegisterTypeFor[groupMessage]()
+	RegisterTypeFor[groupUnknown]()
+}
+
+func TestGroupGolden(t *testing.T) {
+	value := &groupMessage{
+		Single: &groupItem{A: 1, Inner: &groupInner{B: "x"}},
+		Repeated: []*groupItem{
+			{A: 2, Inner: &groupInner{B: "y"}},
+			{A: 3, Inner: &groupInner{B: "z"}},
+		},
+	}
+	want := decodeHex(t, "0b10011b2201781c0c2b10021b2201791c2c2b10031b22017a1c2c")
+	assertMarshal(t, value, want)
+	assertUnmarshal(t, want, value)
+	assertReadWrite[groupMessage](t, want)
+}
+
+func TestGroupUnknownFields(t *testing.T) {
+	data := decodeHex(t, "2201624b5807536505000000544c")
+	assertUnmarshal(t, data, &groupUnknown{B: "b", Unknown: UnknownFields(data[3:])})
+	assertReadWrite[groupUnknown](t, data)
+	if err := Unmarshal(data, new(groupUnknown), WithDiscardUnknown()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroupMismatchedEnd(t *testing.T) {
+	tests := map[string]string{
+		"wrong end":   "0b10010c1c",
+		"missing end": "0b1001",
+		"stray end":   "0c",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Unmarshal(decodeHex(t, data), new(groupMessage)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestGroupMaxDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		return decodeHex(t, strings.Repeat("4b", depth)+strings.Repeat("4c", depth))
+	}
+	if err := Unmarshal(nested(5), new(groupUnknown), WithUnmarshalMaxDepth(10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(nested(20), new(groupUnknown), WithUnmarshalMaxDepth(10)); err == nil {
+		t.Fatal("expected a depth error")
+	}
+	if _, err := Read(TypeName(reflect.TypeFor[groupUnknown]()), nested(20), WithUnmarshalMaxDepth(10)); err == nil {
+		t.Fatal("expected a depth error")
+	}
+	if err := Unmarshal(nested(DefaultMaxDepth+1), new(groupUnknown)); err == nil {
+		t.Fatal("expected a depth error")
+	}
+}
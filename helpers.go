@@ -1,12 +1,52 @@
 package protolizer
 
-import "reflect"
+import (
+	"reflect"
+	"sort"
+)
 
 func dereference(v *reflect.Value) (*reflect.Value, *reflect.Value) {
 	if v.Kind() == reflect.Pointer {
-		v.Set(reflect.New(v.Type().Elem()))
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
 		elem := v.Elem()
 		return &elem, v
 	}
 	return v, v
 }
+
+func sortMapKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Kind() == reflect.Interface {
+			a = a.Elem()
+		}
+		if b.Kind() == reflect.Interface {
+			b = b.Elem()
+		}
+		switch a.Kind() {
+		case reflect.Bool:
+			{
+				return !a.Bool() && b.Bool()
+			}
+		case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+			{
+				return a.Int() < b.Int()
+			}
+		case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+			{
+				return a.Uint() < b.Uint()
+			}
+		case reflect.Float32, reflect.Float64:
+			{
+				return a.Float() < b.Float()
+			}
+		case reflect.String:
+			{
+				return a.String() < b.String()
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,76 @@
+package protolizer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func assertMarshal(t *testing.T, v any, want []byte) {
+	t.Helper()
+	for _, in := range []any{v, reflect.ValueOf(v).Elem().Interface()} {
+		got, err := Marshal(in, WithDeterministic())
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", in, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Marshal(%T) = %x, want %x", in, got, want)
+		}
+	}
+}
+
+func assertUnmarshal[T any](t *testing.T, data []byte, want *T) {
+	t.Helper()
+	got := new(T)
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal(%x): %v", data, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal(%x) = %+v, want %+v", data, got, want)
+	}
+}
+
+func assertReadWrite[T any](t *testing.T, data []byte) {
+	t.Helper()
+	typeName := TypeName(reflect.TypeFor[T]())
+	read, err := Read(typeName, data)
+	if err != nil {
+		t.Fatalf("Read(%x): %v", data, err)
+	}
+	got, err := Write(typeName, read, WithDeterministic())
+	if err != nil {
+		t.Fatalf("Write(%v): %v", read, err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Write(Read(%x)) = %x", data, got)
+	}
+}
+
+func withoutCodecs(tb testing.TB, types ...*Type) {
+	for _, typ := range types {
+		typ.compile()
+		typ.direct.Store(false)
+		for _, f := range typ.Fields {
+			f.codec.Store(nil)
+		}
+	}
+	tb.Cleanup(func() {
+		for _, typ := range types {
+			typ.compiled.Store(0)
+		}
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -3,93 +3,135 @@ package protolizer
 import (
 	"fmt"
 	"reflect"
+	"slices"
+	"time"
 )
 
-func Read(typeName string, bytes []byte) (map[string]any, error) {
-	typ := CaptureTypeByName(typeName)
+func Read(typeName string, bytes []byte, opts ...UnmarshalOption) (map[string]any, error) {
+	return read(typeName, bytes, newUnmarshalOptions(opts))
+}
+
+func read(typeName string, bytes []byte, options *UnmarshalOptions) (map[string]any, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	typ, err := options.registry().lookupByName(typeName)
+	if err != nil {
+		return nil, err
+	}
 	out := make(map[string]any)
 	pos := 0
 	for pos < len(bytes) {
-		fieldNum, _, consumed, err := decodeTag(bytes, pos)
+		fieldNum, wireType, consumed, err := decodeTag(bytes, pos)
 		if err != nil {
 			return nil, err
 		}
+		start := pos
 		pos += consumed
 		field, ok := typ.FieldsIndexer[int(fieldNum)]
 		if !ok {
+			consumed, err := skipValue(bytes, pos, fieldNum, wireType, options)
+			if err != nil {
+				return nil, err
+			}
+			pos += consumed
+			if options.DiscardUnknown {
+				continue
+			}
+			unknown, _ := out[UnknownFieldsKey].([]byte)
+			out[UnknownFieldsKey] = append(unknown, bytes[start:pos]...)
 			continue
 		}
-		value, consumed, err := decodeValueAnonymous(field, bytes, field.Tags.Protobuf.WireType, pos)
+		value, consumed, err := decodeValueAnonymous(field, bytes, wireType, field.Tags.Protobuf, pos, options)
 		if err != nil {
 			return nil, err
 		}
 		pos = consumed
+		if len(field.OneOf) != 0 {
+			if selected, ok := out[field.OneOf].(string); ok && selected != field.Name {
+				delete(out, selected)
+				present, _ := out[PresentFieldsKey].([]string)
+				out[PresentFieldsKey] = slices.DeleteFunc(present, func(name string) bool { return name == selected })
+			}
+			out[field.OneOf] = field.Name
+		}
 		val, ok := out[field.Name]
 		if !ok {
 			out[field.Name] = value
+			if field.hasPresence() {
+				present, _ := out[PresentFieldsKey].([]string)
+				out[PresentFieldsKey] = append(present, field.Name)
+			}
 			continue
 		}
-		switch t := val.(type) {
-		case []any:
-			{
-				t = append(t, value.([]any)...)
-				out[field.Name] = t
-			}
-		case map[string]any:
-			{
-				tmp, ok := value.(map[string]any)
-				if !ok {
-					return nil, fmt.Errorf("expected map[string]any but got %T", value)
-				}
-				for key, value := range tmp {
-					t[key] = value
-				}
-				out[field.Name] = t
-			}
-		case map[float64]any:
-			{
-				tmp, ok := value.(map[float64]any)
-				if !ok {
-					return nil, fmt.Errorf("expected map[string]any but got %T", value)
-				}
-				for key, value := range tmp {
-					t[key] = value
-				}
-				out[field.Name] = t
-			}
-		default:
-			{
-				return nil, fmt.Errorf("unexpected type %T", val)
+		merged, err := mergeAnonymous(field, val, value)
+		if err != nil {
+			return nil, err
+		}
+		out[field.Name] = merged
+	}
+	for _, field := range typ.Fields {
+		if items, ok := out[field.Name].([]any); ok {
+			if err := checkArrayLength(field, len(items)); err != nil {
+				return nil, err
 			}
 		}
 	}
+	if err := fillDefaults(typ, out); err != nil {
+		return nil, err
+	}
 	return out, nil
 }
 
-func decodeValueAnonymous(field *Field, bytes []byte, wireType WireType, pos int) (any, int, error) {
+func decodeValueAnonymous(field *Field, bytes []byte, onWire WireType, info *ProtobufInfo, pos int, options *UnmarshalOptions) (any, int, error) {
+	wireType := info.WireType
+	if info.Wrapper {
+		return decodeWrapperAnonymous(field, bytes, pos, options)
+	}
+	if field.Converted && wireType == WireTypeLen {
+		value, consumed, err := options.decodeBytes(bytes, pos)
+		if err != nil {
+			return nil, pos, err
+		}
+		return value, pos + consumed, nil
+	}
 	switch field.Kind {
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
 		{
-
+			if wireType == WireTypeLen && field.TypeName == durationName {
+				value, consumed, err := decodeDuration(bytes, pos, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return formatDuration(value), pos + consumed, nil
+			}
 			if wireType == WireTypeI32 {
 				value, consumed, err := decodeFixed32(bytes, pos)
 				if err != nil {
 					return nil, pos, err
 				}
-				return float64(value), pos + consumed, nil
+				return anonymousInteger(field, int64(value)), pos + consumed, nil
 			}
 			if wireType == WireTypeI64 {
 				value, consumed, err := decodeFixed64(bytes, pos)
 				if err != nil {
 					return nil, pos, err
 				}
-				return float64(value), pos + consumed, nil
+				return anonymousInteger(field, int64(value)), pos + consumed, nil
+			}
+			if info.ZigZag {
+				value, consumed, err := decodeZigZag(bytes, pos)
+				if err != nil {
+					return nil, pos, err
+				}
+				return anonymousInteger(field, int64(value)), pos + consumed, nil
 			}
 			value, consumed, err := decodeVarint(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
-			return float64(value), pos + consumed, nil
+			return anonymousInteger(field, int64(value)), pos + consumed, nil
 		}
 	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
 		{
@@ -98,20 +140,20 @@ func decodeValueAnonymous(field *Field, bytes []byte, wireType WireType, pos int
 				if err != nil {
 					return nil, pos, err
 				}
-				return float64(value), pos + consumed, nil
+				return anonymousUnsigned(field, uint64(uint32(value))), pos + consumed, nil
 			}
 			if wireType == WireTypeI64 {
 				value, consumed, err := decodeFixed64(bytes, pos)
 				if err != nil {
 					return nil, pos, err
 				}
-				return float64(value), pos + consumed, nil
+				return anonymousUnsigned(field, uint64(value)), pos + consumed, nil
 			}
 			value, consumed, err := decodeUvarint(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
-			return float64(value), pos + consumed, nil
+			return anonymousUnsigned(field, value), pos + consumed, nil
 		}
 	case reflect.Float32:
 		{
@@ -139,7 +181,7 @@ func decodeValueAnonymous(field *Field, bytes []byte, wireType WireType, pos int
 		}
 	case reflect.String:
 		{
-			value, consumed, err := decodeString(bytes, pos)
+			value, consumed, err := options.decodeString(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
@@ -147,81 +189,161 @@ func decodeValueAnonymous(field *Field, bytes []byte, wireType WireType, pos int
 		}
 	case reflect.Array, reflect.Slice:
 		{
+			if info.isListValue(field.Index) {
+				value, consumed, err := viewBytes(bytes, pos)
+				if err != nil {
+					return nil, pos, err
+				}
+				list, err := decodeListValue(value, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return list, pos + consumed, nil
+			}
 			if field.Index == reflect.Uint8 {
-				value, consumed, err := decodeBytes(bytes, pos)
+				value, consumed, err := options.decodeBytes(bytes, pos)
 				if err != nil {
 					return nil, pos, err
 				}
+				if err := checkArrayLength(field, len(value)); err != nil {
+					return nil, pos, err
+				}
 				return value, pos + consumed, nil
 			}
-			switch wireType {
-			case WireTypeVarint, WireTypeI32, WireTypeI64:
-				{
-					value, consumed, err := decodeBytes(bytes, pos)
-					if err != nil {
-						return nil, pos, err
-					}
-					innerPos := 0
-					out := make([]float64, 0)
-					for innerPos < len(value) {
-						value, consumed, err := decodeValueAnonymous(&Field{Kind: field.Index, TypeName: field.IndexType}, value, wireType, innerPos)
-						if err != nil {
-							return nil, pos, err
-						}
-						innerPos = consumed
-						out = append(out, value.(float64))
-					}
-					return out, pos + consumed, nil
+			if onWire == WireTypeLen && info.isScalar() {
+				value, consumed, err := viewBytes(bytes, pos)
+				if err != nil {
+					return nil, pos, err
 				}
-			default:
-				{
-					value, consumed, err := decodeValueAnonymous(&Field{Kind: field.Index, TypeName: field.IndexType}, bytes, wireType, pos)
+				innerPos := 0
+				out := make([]any, 0)
+				for innerPos < len(value) {
+					value, consumed, err := decodeValueAnonymous(field.elemField(), value, wireType, info, innerPos, options)
 					if err != nil {
 						return nil, pos, err
 					}
-					return []any{value}, consumed, nil
+					innerPos = consumed
+					out = append(out, value)
 				}
+				return out, pos + consumed, nil
+			}
+			elem := field.elemField()
+			value, consumed, err := decodeValueAnonymous(elem, bytes, onWire, info.elementInfo(elem.Kind, elem.Key, elem.Index), pos, options)
+			if err != nil {
+				return nil, pos, err
 			}
+			return []any{value}, consumed, nil
 		}
 	case reflect.Map:
 		{
-			value, c, err := decodeBytes(bytes, pos)
+			value, c, err := viewBytes(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
-			innerPos := 0
-			_, keyWireType, consumed, err := decodeTag(value, innerPos)
+			if info.isStruct(field.Key, field.Index) {
+				fields, err := decodeStruct(value, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return fields, pos + c, nil
+			}
+			keyInfo, valueInfo := field.Tags.mapKeyInfo(), field.Tags.mapValueInfo()
+			keyField := field.keyField()
+			valueField := field.elemField()
+			key, err := zeroAnonymous(keyField, keyInfo, options)
 			if err != nil {
 				return nil, pos, err
 			}
-			innerPos += consumed
-			key, consumed, err := decodeValueAnonymous(&Field{Kind: field.Key, TypeName: field.KeyType}, value, keyWireType, innerPos)
+			v, err := zeroAnonymous(valueField, valueInfo, options)
 			if err != nil {
 				return nil, pos, err
 			}
-			innerPos = consumed
-
-			_, valueWireType, consumed, err := decodeTag(value, innerPos)
+			innerPos := 0
+			for innerPos < len(value) {
+				fieldNum, wireType, consumed, err := decodeTag(value, innerPos)
+				if err != nil {
+					return nil, pos, err
+				}
+				innerPos += consumed
+				switch fieldNum {
+				case 1:
+					{
+						key, innerPos, err = decodeValueAnonymous(keyField, value, wireType, keyInfo, innerPos, options)
+					}
+				case 2:
+					{
+						v, innerPos, err = decodeValueAnonymous(valueField, value, wireType, valueInfo, innerPos, options)
+					}
+				default:
+					{
+						consumed, err = skipValue(value, innerPos, fieldNum, wireType, options)
+						innerPos += consumed
+					}
+				}
+				if err != nil {
+					return nil, pos, err
+				}
+			}
+			switch key := key.(type) {
+			case float64:
+				{
+					return map[float64]any{key: v}, pos + c, nil
+				}
+			case string:
+				{
+					return map[string]any{key: v}, pos + c, nil
+				}
+			case bool:
+				{
+					return map[bool]any{key: v}, pos + c, nil
+				}
+			}
+			return map[any]any{key: v}, pos + c, nil
+		}
+	case reflect.Struct:
+		{
+			if field.TypeName == timeName {
+				value, consumed, err := decodeTimestamp(bytes, pos, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return value.Format(time.RFC3339Nano), pos + consumed, nil
+			}
+			if wireType == WireTypeSGroup {
+				value, c, err := decodeGroup(bytes, pos, int32(info.FieldNum), options)
+				if err != nil {
+					return nil, pos, err
+				}
+				v, err := read(field.TypeName, value, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return v, pos + c, nil
+			}
+			value, c, err := viewBytes(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
-			innerPos += consumed
-			v, _, err := decodeValueAnonymous(&Field{Kind: field.Index, TypeName: field.IndexType}, value, valueWireType, innerPos)
+			if field.TypeName == anyName {
+				v, err := readAny(value, options)
+				if err != nil {
+					return nil, pos, err
+				}
+				return v, pos + c, nil
+			}
+			v, err := read(field.TypeName, value, options)
 			if err != nil {
 				return nil, pos, err
 			}
-			if keyWireType == WireTypeVarint || keyWireType == WireTypeI32 || keyWireType == WireTypeI64 {
-				return map[float64]any{key.(float64): v}, pos + c, nil
-			}
-			return map[any]any{key: v}, pos + c, nil
+			return v, pos + c, nil
 		}
-	case reflect.Struct:
+	case reflect.Interface:
 		{
-			value, c, err := decodeBytes(bytes, pos)
+			value, c, err := viewBytes(bytes, pos)
 			if err != nil {
 				return nil, pos, err
 			}
-			v, err := Read(field.TypeName, value)
+			v, err := decodeStructValue(value, options)
 			if err != nil {
 				return nil, pos, err
 			}
@@ -231,63 +353,119 @@ func decodeValueAnonymous(field *Field, bytes []byte, wireType WireType, pos int
 	return nil, pos, fmt.Errorf("unexpected type %v", field)
 }
 
-func Write(typeName string, v map[string]any) ([]byte, error) {
-	typ := CaptureTypeByName(typeName)
+func Write(typeName string, v map[string]any, opts ...MarshalOption) ([]byte, error) {
+	return write(typeName, v, newMarshalOptions(opts))
+}
+
+func write(typeName string, v map[string]any, options *MarshalOptions) ([]byte, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	typ, err := options.registry().lookupByName(typeName)
+	if err != nil {
+		return nil, err
+	}
 	out := make([]byte, 0)
 	for _, i := range typ.Fields {
-		var opts []encodeOption
+		var opts []codecOption
 		value, ok := v[i.Name]
 		if !ok {
 			continue
 		}
+		if selected, ok := v[i.OneOf].(string); ok && len(i.OneOf) != 0 && selected != i.Name {
+			continue
+		}
+		if isFilledDefault(i, v) {
+			continue
+		}
 		v := reflect.ValueOf(value)
 		if v.Kind() == reflect.Map {
-			opts = append(opts, withMapWireTypes(i.Tags.MapKey, i.Tags.MapValue))
-		}
-		w := i.Tags.Protobuf.WireType
-		if i.Kind == reflect.Slice {
-			w = WireTypeLen
+			opts = append(opts, withMapInfo(i.Tags.mapKeyInfo(), i.Tags.mapValueInfo()))
 		}
-		tag, err := encodeTag(int32(i.Tags.Protobuf.FieldNum), w)
+		tag, err := encodeTag(int32(i.Tags.Protobuf.FieldNum), i.tagWireType())
 		if err != nil {
 			return nil, err
 		}
-		if v.IsZero() {
+		if !i.isPresent(v) {
 			continue
 		}
 		if v.Kind() == reflect.Pointer {
 			v = v.Elem()
 		}
-		bytes, err := encodeValueAnonymous(&v, i, i.Kind, i.Tags.Protobuf.FieldNum, i.Tags.Protobuf.WireType, opts...)
+		if v.Kind() == reflect.Array || v.Kind() == reflect.Slice {
+			if err := checkArrayLength(i, v.Len()); err != nil {
+				return nil, err
+			}
+		}
+		bytes, err := encodeValueAnonymous(&v, i, i.Kind, i.Tags.Protobuf, options, opts...)
 		if err != nil {
 			return nil, err
 		}
 		out = append(out, append(tag, bytes...)...)
 	}
+	switch unknown := v[UnknownFieldsKey].(type) {
+	case []byte:
+		{
+			out = append(out, unknown...)
+		}
+	case UnknownFields:
+		{
+			out = append(out, unknown...)
+		}
+	}
 	return out, nil
 }
 
-func encodeValueAnonymous(v *reflect.Value, field *Field, kind reflect.Kind, fieldNumber int, wireType WireType, opts ...encodeOption) ([]byte, error) {
+func encodeValueAnonymous(v *reflect.Value, field *Field, kind reflect.Kind, info *ProtobufInfo, options *MarshalOptions, opts ...codecOption) ([]byte, error) {
+	fieldNumber, wireType := info.FieldNum, info.WireType
+	if info.Wrapper {
+		return encodeWrapperAnonymous(v, field, options)
+	}
+	if field.Converted && wireType == WireTypeLen {
+		if v.Kind() == reflect.String {
+			return encodeString(v.String()), nil
+		}
+		return encodeBytes(arrayBytes(*v)), nil
+	}
 	switch kind {
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
 		{
+			if wireType == WireTypeLen && elemTypeName(field) == durationName {
+				value, err := parseDuration(*v)
+				if err != nil {
+					return nil, err
+				}
+				return appendDuration(nil, value), nil
+			}
+			value, err := anonymousNumber(v, field)
+			if err != nil {
+				return nil, err
+			}
 			if wireType == WireTypeI32 {
-				return encodeFixed32(int32(v.Float())), nil
+				return encodeFixed32(int32(value)), nil
 			}
 			if wireType == WireTypeI64 {
-				return encodeFixed64(int64(v.Float())), nil
+				return encodeFixed64(value), nil
 			}
-			return encodeVarint(int64(v.Float())), nil
+			if info.ZigZag {
+				return encodeZigZag(value), nil
+			}
+			return encodeVarint(value), nil
 		}
 	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
 		{
+			value, err := anonymousUnsignedNumber(v, field)
+			if err != nil {
+				return nil, err
+			}
 			if wireType == WireTypeI32 {
-				return encodeFixed32(int32(v.Float())), nil
+				return encodeFixed32(int32(value)), nil
 			}
 			if wireType == WireTypeI64 {
-				return encodeFixed64(int64(v.Float())), nil
+				return encodeFixed64(int64(value)), nil
 			}
-			return encodeUvarint(uint64(v.Float())), nil
+			return encodeUvarint(value), nil
 		}
 	case reflect.Float32:
 		{
@@ -308,60 +486,73 @@ func encodeValueAnonymous(v *reflect.Value, field *Field, kind reflect.Kind, fie
 	case reflect.Array, reflect.Slice:
 		{
 			k := field.Index
+			if info.isListValue(k) {
+				data, err := encodeListValue(nil, *v, options)
+				if err != nil {
+					return nil, err
+				}
+				return encodeBytes(data), nil
+			}
 			if k == reflect.Uint8 {
-				return encodeBytes(v.Bytes()), nil
+				return encodeBytes(arrayBytes(*v)), nil
 			}
 			var data []byte
-			switch wireType {
-			case WireTypeVarint, WireTypeI32, WireTypeI64:
-				{
-					for i := 0; i < v.Len(); i++ {
-						v := v.Index(i)
-						if v.Kind() == reflect.Pointer {
-							v = v.Elem()
-						}
-						bytes, err := encodeValueAnonymous(&v, field, field.Index, fieldNumber, wireType)
-						if err != nil {
-							return nil, err
-						}
-						data = append(data, bytes...)
+			if info.isPacked() {
+				for i := 0; i < v.Len(); i++ {
+					v := v.Index(i)
+					if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+						v = v.Elem()
+					}
+					bytes, err := encodeValueAnonymous(&v, field.elemField(), field.Index, info, options)
+					if err != nil {
+						return nil, err
 					}
-					return encodeBytes(data), nil
+					data = append(data, bytes...)
 				}
-			default:
-				{
-					for i := 0; i < v.Len(); i++ {
-						if len(data) != 0 {
-							tag, err := encodeTag(int32(fieldNumber), WireTypeLen)
-							if err != nil {
-								return nil, err
-							}
-							data = append(data, tag...)
-						}
-						v := v.Index(i)
-						if v.Kind() == reflect.Pointer {
-							v = v.Elem()
-						}
-						bytes, err := encodeValueAnonymous(&v, field, field.Index, fieldNumber, wireType)
-						if err != nil {
-							return nil, err
-						}
-						data = append(data, bytes...)
+				return encodeBytes(data), nil
+			}
+			elem := field.elemField()
+			elemInfo := info.elementInfo(elem.Kind, elem.Key, elem.Index)
+			for i := 0; i < v.Len(); i++ {
+				if i != 0 {
+					tag, err := encodeTag(int32(fieldNumber), wireType)
+					if err != nil {
+						return nil, err
 					}
-					return data, nil
+					data = append(data, tag...)
+				}
+				v := v.Index(i)
+				if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+					v = v.Elem()
+				}
+				bytes, err := encodeValueAnonymous(&v, elem, field.Index, elemInfo, options)
+				if err != nil {
+					return nil, err
 				}
+				data = append(data, bytes...)
 			}
+			return data, nil
 
 		}
 	case reflect.Map:
 		{
-			encodeOptions := new(encodeOptions)
+			if info.isStruct(field.Key, field.Index) {
+				data, err := encodeStruct(nil, *v, options)
+				if err != nil {
+					return nil, err
+				}
+				return encodeBytes(data), nil
+			}
+			codecOptions := new(codecOptions)
 			for _, opt := range opts {
-				opt(encodeOptions)
+				opt(codecOptions)
 			}
 			var data []byte
-			mapRange := v.MapRange()
-			for mapRange.Next() {
+			keys := v.MapKeys()
+			if options.Deterministic {
+				sortMapKeys(keys)
+			}
+			for _, key := range keys {
 				if len(data) != 0 {
 					tag, err := encodeTag(int32(fieldNumber), WireTypeLen)
 					if err != nil {
@@ -369,27 +560,26 @@ func encodeValueAnonymous(v *reflect.Value, field *Field, kind reflect.Kind, fie
 					}
 					data = append(data, tag...)
 				}
-				key := mapRange.Key()
-				if key.Kind() == reflect.Pointer {
+				value := v.MapIndex(key)
+				if key.Kind() == reflect.Pointer || key.Kind() == reflect.Interface {
 					key = key.Elem()
 				}
-				keyTag, err := encodeTag(1, encodeOptions.MapKeyWireType)
+				keyTag, err := encodeTag(1, codecOptions.MapKeyInfo.WireType)
 				if err != nil {
 					return nil, err
 				}
-				keyBytes, err := encodeValueAnonymous(&key, field, field.Key, fieldNumber, encodeOptions.MapKeyWireType)
+				keyBytes, err := encodeValueAnonymous(&key, field.keyField(), field.Key, codecOptions.MapKeyInfo, options)
 				if err != nil {
 					return nil, err
 				}
-				value := mapRange.Value()
-				valueTag, err := encodeTag(2, encodeOptions.MapValueWireType)
+				valueTag, err := encodeTag(2, codecOptions.MapValueInfo.WireType)
 				if err != nil {
 					return nil, err
 				}
-				if value.Kind() == reflect.Pointer {
+				if value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
 					value = value.Elem()
 				}
-				valueBytes, err := encodeValueAnonymous(&value, field, field.Index, fieldNumber, encodeOptions.MapValueWireType)
+				valueBytes, err := encodeValueAnonymous(&value, field.elemField(), field.Index, codecOptions.MapValueInfo, options)
 				if err != nil {
 					return nil, err
 				}
@@ -403,24 +593,174 @@ func encodeValueAnonymous(v *reflect.Value, field *Field, kind reflect.Kind, fie
 		}
 	case reflect.Struct:
 		{
-
-			typeName := field.TypeName
-			if len(field.IndexType) != 0 {
-				typeName = field.IndexType
+			typeName := elemTypeName(field)
+			if typeName == timeName {
+				value, err := parseTimestamp(*v)
+				if err != nil {
+					return nil, err
+				}
+				return appendTimestamp(nil, value)
+			}
+			if typeName == anyName {
+				data, err := writeAny(v.Interface().(map[string]any), options)
+				if err != nil {
+					return nil, err
+				}
+				return encodeBytes(data), nil
 			}
-			data, err := Write(typeName, v.Interface().(map[string]any))
+			data, err := write(typeName, v.Interface().(map[string]any), options)
 			if err != nil {
 				return nil, err
 			}
+			if wireType == WireTypeSGroup {
+				return encodeGroup(int32(fieldNumber), data)
+			}
 			out := encodeBytes(data)
 			return out, nil
 
 		}
 	case reflect.Interface:
 		{
-			elem := v.Elem()
-			return encodeValueAnonymous(&elem, field, field.Kind, fieldNumber, wireType)
+			data, err := encodeStructValue(nil, *v, options)
+			if err != nil {
+				return nil, err
+			}
+			return encodeBytes(data), nil
 		}
 	}
 	return nil, fmt.Errorf("unexpected type %v", kind)
 }
+
+func anonymousInteger(field *Field, value int64) any {
+	if enum := field.enum(); enum != nil {
+		if name, ok := enum.Values[int32(value)]; ok {
+			return name
+		}
+	}
+	return float64(value)
+}
+
+func anonymousUnsigned(field *Field, value uint64) any {
+	if enum := field.enum(); enum != nil {
+		if name, ok := enum.Values[int32(value)]; ok {
+			return name
+		}
+	}
+	return float64(value)
+}
+
+func anonymousNumber(v *reflect.Value, field *Field) (int64, error) {
+	switch v.Kind() {
+	case reflect.String:
+		{
+			enum := field.enum()
+			if enum == nil {
+				return 0, fmt.Errorf("field %s is not a registered enum", field.Name)
+			}
+			number, ok := enum.Numbers[v.String()]
+			if !ok {
+				return 0, fmt.Errorf("unknown value %q for enum %s", v.String(), enum.Name)
+			}
+			return int64(number), nil
+		}
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			return v.Int(), nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			return int64(v.Uint()), nil
+		}
+	}
+	return int64(v.Float()), nil
+}
+
+func anonymousUnsignedNumber(v *reflect.Value, field *Field) (uint64, error) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		{
+			return uint64(v.Float()), nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			return v.Uint(), nil
+		}
+	}
+	value, err := anonymousNumber(v, field)
+	return uint64(value), err
+}
+
+func (f *Field) elemField() *Field {
+	out := &Field{Name: f.Name, Kind: f.Index, Key: f.elemKey, Index: f.elemIndex, TypeName: f.IndexType, Enum: f.Enum, Converted: f.IndexConverted, registry: f.registry}
+	if (out.Kind == reflect.Array || out.Kind == reflect.Slice) && out.Index == reflect.Invalid {
+		out.Index = reflect.Uint8
+	}
+	return out
+}
+
+func (f *Field) keyField() *Field {
+	return &Field{Name: f.Name, Kind: f.Key, TypeName: f.KeyType, registry: f.registry}
+}
+
+func elemTypeName(field *Field) string {
+	if len(field.IndexType) != 0 {
+		return field.IndexType
+	}
+	return field.TypeName
+}
+
+func zeroAnonymous(field *Field, info *ProtobufInfo, options *UnmarshalOptions) (any, error) {
+	if field.Converted && info.WireType == WireTypeLen {
+		return []byte{}, nil
+	}
+	switch field.Kind {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			if info.WireType == WireTypeLen && field.TypeName == durationName {
+				return formatDuration(0), nil
+			}
+			return anonymousInteger(field, 0), nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			return anonymousUnsigned(field, 0), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		{
+			return float64(0), nil
+		}
+	case reflect.Bool:
+		{
+			return false, nil
+		}
+	case reflect.String:
+		{
+			return "", nil
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			if info.isListValue(field.Index) {
+				return []any{}, nil
+			}
+			return []byte{}, nil
+		}
+	case reflect.Map:
+		{
+			if info.isStruct(field.Key, field.Index) {
+				return map[string]any{}, nil
+			}
+		}
+	case reflect.Struct:
+		{
+			if field.TypeName == timeName {
+				return time.Unix(0, 0).UTC().Format(time.RFC3339Nano), nil
+			}
+			return read(field.TypeName, nil, options)
+		}
+	case reflect.Interface:
+		{
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected type %v", field.Kind)
+}
@@ -0,0 +1,80 @@
+package protolizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type (
+	mapColor int32
+	mapValue struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	mapMessage struct {
+		Strings  map[string]string    `protobuf:"bytes,1,rep,name=strings,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+		Bools    map[bool]int32       `protobuf:"bytes,2,rep,name=bools,proto3" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+		Sints    map[int32]int64      `protobuf:"bytes,3,rep,name=sints,proto3" protobuf_key:"zigzag32,1,opt,name=key,proto3" protobuf_val:"zigzag64,2,opt,name=value,proto3"`
+		Fixed    map[uint64]uint32    `protobuf:"bytes,4,rep,name=fixed,proto3" protobuf_key:"fixed64,1,opt,name=key,proto3" protobuf_val:"fixed32,2,opt,name=value,proto3"`
+		Messages map[string]*mapValue `protobuf:"bytes,5,rep,name=messages,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+		Enums    map[int32]mapColor   `protobuf:"bytes,6,rep,name=enums,proto3" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=mapColor"`
+	}
+)
+
+func init() {
+	RegisterEnum[mapColor](map[int32]string{0: "NONE", 1: "RED", 2: "GREEN"})
+	RegisterTypeFor[mapValue]()
+	RegisterTypeFor[mapMessage]()
+}
+
+func TestMapGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *mapMessage
+		want  string
+	}{
+		{"strings", &mapMessage{Strings: map[string]string{"k": "v"}}, "0a060a016b120176"},
+		{"bools", &mapMessage{Bools: map[bool]int32{true: 7}}, "120408011007"},
+		{"zigzag", &mapMessage{Sints: map[int32]int64{-5: -6}}, "1a040809100b"},
+		{"fixed", &mapMessage{Fixed: map[uint64]uint32{9: 10}}, "220e090900000000000000150a000000"},
+		{"messages", &mapMessage{Messages: map[string]*mapValue{"m": {V: 3}}}, "2a070a016d12020803"},
+		{"enums", &mapMessage{Enums: map[int32]mapColor{1: 2}}, "320408011002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[mapMessage](t, want)
+		})
+	}
+}
+
+func TestMapEntryDecoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *mapMessage
+		read map[string]any
+	}{
+		{"reversed", "0a061201760a016b", &mapMessage{Strings: map[string]string{"k": "v"}}, map[string]any{"k": "v"}},
+		{"missing key", "0a03120176", &mapMessage{Strings: map[string]string{"": "v"}}, map[string]any{"": "v"}},
+		{"missing value", "0a030a016b", &mapMessage{Strings: map[string]string{"k": ""}}, map[string]any{"k": ""}},
+		{"empty entry", "0a00", &mapMessage{Strings: map[string]string{"": ""}}, map[string]any{"": ""}},
+		{"unknown fields", "0a0c0a016b4b08014c1804120176", &mapMessage{Strings: map[string]string{"k": "v"}}, map[string]any{"k": "v"}},
+		{"duplicate key", "0a060a016b1201610a060a016b120162", &mapMessage{Strings: map[string]string{"k": "b"}}, map[string]any{"k": "b"}},
+		{"missing message", "2a030a016d", &mapMessage{Messages: map[string]*mapValue{"m": {}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := decodeHex(t, tt.data)
+			assertUnmarshal(t, data, tt.want)
+			read, err := Read(TypeName(reflect.TypeFor[mapMessage]()), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.read != nil && !reflect.DeepEqual(read["Strings"], tt.read) {
+				t.Fatalf("Read(%x) = %v, want %v", data, read["Strings"], tt.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+func mergeAnonymous(field *Field, dst any, src any) (any, error) {
+	switch t := dst.(type) {
+	case []any:
+		{
+			tmp, ok := src.([]any)
+			if !ok {
+				return nil, fmt.Errorf("expected []any but got %T", src)
+			}
+			return append(t, tmp...), nil
+		}
+	case map[string]any:
+		{
+			tmp, ok := src.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected map[string]any but got %T", src)
+			}
+			if typ := field.messageType(); typ != nil {
+				return t, mergeMessageAnonymous(typ, t, tmp)
+			}
+			for key, value := range tmp {
+				t[key] = value
+			}
+			return t, nil
+		}
+	case map[float64]any:
+		{
+			tmp, ok := src.(map[float64]any)
+			if !ok {
+				return nil, fmt.Errorf("expected map[float64]any but got %T", src)
+			}
+			for key, value := range tmp {
+				t[key] = value
+			}
+			return t, nil
+		}
+	case map[bool]any:
+		{
+			tmp, ok := src.(map[bool]any)
+			if !ok {
+				return nil, fmt.Errorf("expected map[bool]any but got %T", src)
+			}
+			for key, value := range tmp {
+				t[key] = value
+			}
+			return t, nil
+		}
+	}
+	return src, nil
+}
+
+func mergeMessageAnonymous(typ *Type, dst map[string]any, src map[string]any) error {
+	present, _ := dst[PresentFieldsKey].([]string)
+	srcPresent, _ := src[PresentFieldsKey].([]string)
+	for _, field := range typ.Fields {
+		value, ok := src[field.Name]
+		if !ok || field.hasDefault() && !slices.Contains(srcPresent, field.Name) {
+			continue
+		}
+		if len(field.OneOf) != 0 {
+			if selected, ok := dst[field.OneOf].(string); ok && selected != field.Name {
+				delete(dst, selected)
+				present = slices.DeleteFunc(present, func(name string) bool { return name == selected })
+			}
+			dst[field.OneOf] = field.Name
+		}
+		val, ok := dst[field.Name]
+		if !ok {
+			dst[field.Name] = value
+			continue
+		}
+		merged, err := mergeAnonymous(field, val, value)
+		if err != nil {
+			return err
+		}
+		dst[field.Name] = merged
+	}
+	for _, name := range srcPresent {
+		if !slices.Contains(present, name) {
+			present = append(present, name)
+		}
+	}
+	if _, ok := src[PresentFieldsKey]; ok {
+		dst[PresentFieldsKey] = present
+	}
+	if unknown, ok := src[UnknownFieldsKey].([]byte); ok {
+		existing, _ := dst[UnknownFieldsKey].([]byte)
+		dst[UnknownFieldsKey] = append(existing, unknown...)
+	}
+	return nil
+}
+
+func (f *Field) messageType() *Type {
+	if f.Kind != reflect.Struct || f.Converted || f.TypeName == timeName || f.TypeName == anyName {
+		return nil
+	}
+	return registryOf(f.registry).CaptureTypeByName(f.TypeName)
+}
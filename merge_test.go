@@ -0,0 +1,149 @@
+package protolizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type (
+	mergeInner struct {
+		Name  string   `protobuf:"bytes,1,opt,name=name,proto3"`
+		Count int32    `protobuf:"varint,2,opt,name=count,proto3"`
+		Tags  []string `protobuf:"bytes,3,rep,name=tags,proto3"`
+	}
+	mergeMessage struct {
+		Id     int64            `protobuf:"varint,1,opt,name=id,proto3"`
+		Label  string           `protobuf:"bytes,2,opt,name=label,proto3"`
+		Values []int32          `protobuf:"varint,3,rep,packed,name=values,proto3"`
+		Attrs  map[string]int32 `protobuf:"bytes,4,rep,name=attrs,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+		Inner  *mergeInner      `protobuf:"bytes,5,opt,name=inner,proto3"`
+		Items  []*mergeInner    `protobuf:"bytes,6,rep,name=items,proto3"`
+	}
+)
+
+func init() {
+	RegisterTypeFor[mergeInner]()
+	RegisterTypeFor[mergeMessage]()
+}
+
+func TestMergeConcatenated(t *testing.T) {
+	first := &mergeMessage{
+		Id:     1,
+		Label:  "first",
+		Values: []int32{1, 2},
+		Attrs:  map[string]int32{"a": 1, "b": 2},
+		Inner:  &mergeInner{Name: "inner", Count: 3, Tags: []string{"x"}},
+		Items:  []*mergeInner{{Name: "one"}},
+	}
+	second := &mergeMessage{
+		Id:     2,
+		Values: []int32{3},
+		Attrs:  map[string]int32{"b": 20, "c": 30},
+		Inner:  &mergeInner{Count: 4, Tags: []string{"y"}},
+		Items:  []*mergeInner{{Name: "two"}},
+	}
+	want := &mergeMessage{
+		Id:     2,
+		Label:  "first",
+		Values: []int32{1, 2, 3},
+		Attrs:  map[string]int32{"a": 1, "b": 20, "c": 30},
+		Inner:  &mergeInner{Name: "inner", Count: 4, Tags: []string{"x", "y"}},
+		Items:  []*mergeInner{{Name: "one"}, {Name: "two"}},
+	}
+	a, err := Marshal(first, WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(second, WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(a, b...)
+	assertUnmarshal(t, data, want)
+
+	name := TypeName(reflect.TypeFor[mergeMessage]())
+	got, err := Read(name, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := Marshal(want, WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Read(name, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Read = %#v, want %#v", got, expected)
+	}
+}
+
+func TestMergeRepeatedOccurrences(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *mergeMessage
+	}{
+		{"scalar", "080108020803", &mergeMessage{Id: 3}},
+		{"nested", "2a030a01612a021002", &mergeMessage{Inner: &mergeInner{Name: "a", Count: 2}}},
+		{"packed and unpacked", "18011a020203", &mergeMessage{Values: []int32{1, 2, 3}}},
+		{"map key", "22050a0161100122050a01611002", &mergeMessage{Attrs: map[string]int32{"a": 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := decodeHex(t, tt.data)
+			assertUnmarshal(t, data, tt.want)
+			t.Run("reflect", func(t *testing.T) {
+				withoutCodecs(t, CaptureTypeFor[mergeMessage](), CaptureTypeFor[mergeInner]())
+				assertUnmarshal(t, data, tt.want)
+			})
+		})
+	}
+}
+
+func TestUnmarshalReset(t *testing.T) {
+	data, err := Marshal(&mergeMessage{Id: 5, Values: []int32{9}, Attrs: map[string]int32{"b": 2}, Inner: &mergeInner{Count: 1}}, WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	populated := func() *mergeMessage {
+		return &mergeMessage{Label: "kept", Values: []int32{1}, Attrs: map[string]int32{"a": 1}, Inner: &mergeInner{Name: "inner"}, Items: []*mergeInner{{Name: "one"}}}
+	}
+	codec, err := CodecFor[mergeMessage]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoders := []struct {
+		name      string
+		unmarshal func([]byte, *mergeMessage, ...UnmarshalOption) error
+	}{
+		{"Unmarshal", func(data []byte, v *mergeMessage, opts ...UnmarshalOption) error { return Unmarshal(data, v, opts...) }},
+		{"Codec", codec.Unmarshal},
+	}
+	for _, decoder := range decoders {
+		t.Run(decoder.name, func(t *testing.T) {
+			got := populated()
+			inner := got.Inner
+			if err := decoder.unmarshal(data, got); err != nil {
+				t.Fatal(err)
+			}
+			want := &mergeMessage{Id: 5, Label: "kept", Values: []int32{1, 9}, Attrs: map[string]int32{"a": 1, "b": 2}, Inner: &mergeInner{Name: "inner", Count: 1}, Items: []*mergeInner{{Name: "one"}}}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("without WithReset = %+v, want %+v", got, want)
+			}
+			if got.Inner != inner {
+				t.Fatal("the nested pointer was replaced instead of reused")
+			}
+
+			got = populated()
+			if err := decoder.unmarshal(data, got, WithReset()); err != nil {
+				t.Fatal(err)
+			}
+			want = &mergeMessage{Id: 5, Values: []int32{9}, Attrs: map[string]int32{"b": 2}, Inner: &mergeInner{Count: 1}}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("with WithReset = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package protolizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type (
+	oneofChild struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	oneofMessage struct {
+		Name  string               `protobuf:"bytes,1,opt,name=name,proto3"`
+		Value isOneofMessage_Value `protobuf_oneof:"value"`
+	}
+	isOneofMessage_Value interface {
+		isOneofMessage_Value()
+	}
+	oneofMessage_Text struct {
+		Text string `protobuf:"bytes,2,opt,name=text,proto3,oneof"`
+	}
+	oneofMessage_Code struct {
+		Code int32 `protobuf:"varint,3,opt,name=code,proto3,oneof"`
+	}
+	oneofMessage_Child struct {
+		Child *oneofChild `protobuf:"bytes,4,opt,name=child,proto3,oneof"`
+	}
+)
+
+func (*oneofMessage_Text) isOneofMessage_Value()  {}
+func (*oneofMessage_Code) isOneofMessage_Value()  {}
+func (*oneofMessage_Child) isOneofMessage_Value() {}
+
+func (*oneofMessage) XXX_OneofWrappers() []any {
+	return []any{
+		(*oneofMessage_Text)(nil),
+		(*oneofMessage_Code)(nil),
+		(*oneofMessage_Child)(nil),
+	}
+}
+
+func init() {
+	RegisterTypeFor[oneofChild]()
+	RegisterTypeFor[oneofMessage]()
+}
+
+func TestOneofGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *oneofMessage
+		want  string
+	}{
+		{"unset", &oneofMessage{Name: "n"}, "0a016e"},
+		{"empty string", &oneofMessage{Name: "n", Value: &oneofMessage_Text{}}, "0a016e1200"},
+		{"zero number", &oneofMessage{Name: "n", Value: &oneofMessage_Code{}}, "0a016e1800"},
+		{"message", &oneofMessage{Value: &oneofMessage_Child{Child: &oneofChild{V: 1}}}, "22020801"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[oneofMessage](t, want)
+		})
+	}
+}
+
+func TestOneofLastCaseWins(t *testing.T) {
+	data := decodeHex(t, "0a016e1200"+"1800")
+	assertUnmarshal(t, data, &oneofMessage{Name: "n", Value: &oneofMessage_Code{}})
+	read, err := Read(TypeName(reflect.TypeFor[oneofMessage]()), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read["Value"] != "Code" {
+		t.Fatalf("active case = %v, want Code", read["Value"])
+	}
+}
@@ -0,0 +1,193 @@
+package protolizer
+
+import (
+	"fmt"
+	"sync"
+)
+
+type (
+	MarshalOptions struct {
+		Deterministic bool
+		AllowPartial  bool
+		MaxDepth      int
+		Registry      *Registry
+		depth         int
+		cache         *sizeCache
+	}
+	MarshalOption func(*MarshalOptions)
+
+	UnmarshalOptions struct {
+		AllowPartial   bool
+		DiscardUnknown bool
+		Reset          bool
+		AliasBytes     bool
+		AliasStrings   bool
+		MaxDepth       int
+		Registry       *Registry
+		depth          int
+	}
+	UnmarshalOption func(*UnmarshalOptions)
+)
+
+const (
+	DefaultMaxDepth = 10000
+)
+
+var (
+	_marshalOptions = sync.Pool{
+		New: func() any {
+			return &MarshalOptions{cache: new(sizeCache)}
+		},
+	}
+	_unmarshalOptions = sync.Pool{
+		New: func() any {
+			return new(UnmarshalOptions)
+		},
+	}
+)
+
+func WithDeterministic() MarshalOption {
+	return func(mo *MarshalOptions) {
+		mo.Deterministic = true
+	}
+}
+
+func WithMarshalAllowPartial() MarshalOption {
+	return func(mo *MarshalOptions) {
+		mo.AllowPartial = true
+	}
+}
+
+func WithMarshalMaxDepth(depth int) MarshalOption {
+	return func(mo *MarshalOptions) {
+		mo.MaxDepth = depth
+	}
+}
+
+func WithMarshalRegistry(registry *Registry) MarshalOption {
+	return func(mo *MarshalOptions) {
+		mo.Registry = registry
+	}
+}
+
+func WithUnmarshalAllowPartial() UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.AllowPartial = true
+	}
+}
+
+func WithDiscardUnknown() UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.DiscardUnknown = true
+	}
+}
+
+func WithReset() UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.Reset = true
+	}
+}
+
+func WithAliasBytes() UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.AliasBytes = true
+	}
+}
+
+func WithAliasStrings() UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.AliasStrings = true
+	}
+}
+
+func WithUnmarshalMaxDepth(depth int) UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.MaxDepth = depth
+	}
+}
+
+func WithUnmarshalRegistry(registry *Registry) UnmarshalOption {
+	return func(uo *UnmarshalOptions) {
+		uo.Registry = registry
+	}
+}
+
+func newMarshalOptions(opts []MarshalOption) *MarshalOptions {
+	out := new(MarshalOptions)
+	for _, opt := range opts {
+		opt(out)
+	}
+	return out
+}
+
+func newUnmarshalOptions(opts []UnmarshalOption) *UnmarshalOptions {
+	out := new(UnmarshalOptions)
+	for _, opt := range opts {
+		opt(out)
+	}
+	return out
+}
+
+func acquireMarshalOptions(opts []MarshalOption) *MarshalOptions {
+	out := _marshalOptions.Get().(*MarshalOptions)
+	for _, opt := range opts {
+		opt(out)
+	}
+	return out
+}
+
+func acquireUnmarshalOptions(opts []UnmarshalOption) *UnmarshalOptions {
+	out := _unmarshalOptions.Get().(*UnmarshalOptions)
+	for _, opt := range opts {
+		opt(out)
+	}
+	return out
+}
+
+func (mo *MarshalOptions) release() {
+	cache := mo.cache
+	cache.reset()
+	*mo = MarshalOptions{cache: cache}
+	_marshalOptions.Put(mo)
+}
+
+func (uo *UnmarshalOptions) release() {
+	*uo = UnmarshalOptions{}
+	_unmarshalOptions.Put(uo)
+}
+
+func (mo *MarshalOptions) registry() *Registry {
+	return registryOf(mo.Registry)
+}
+
+func (uo *UnmarshalOptions) registry() *Registry {
+	return registryOf(uo.Registry)
+}
+
+func (mo *MarshalOptions) enter() error {
+	mo.depth++
+	return checkDepth(mo.depth, mo.MaxDepth)
+}
+
+func (mo *MarshalOptions) leave() {
+	mo.depth--
+}
+
+func (uo *UnmarshalOptions) enter() error {
+	uo.depth++
+	return checkDepth(uo.depth, uo.MaxDepth)
+}
+
+func (uo *UnmarshalOptions) leave() {
+	uo.depth--
+}
+
+func checkDepth(depth int, max int) error {
+	if max <= 0 {
+		max = DefaultMaxDepth
+	}
+	if depth > max {
+		return fmt.Errorf("exceeded maximum depth of %d", max)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type packedMessage struct {
+	Packed   []int32   `protobuf:"varint,1,rep,packed,name=packed,proto3"`
+	Unpacked []int32   `protobuf:"varint,2,rep,name=unpacked"`
+	Default  []uint64  `protobuf:"varint,3,rep,name=default,proto3"`
+	OptOut   []int32   `protobuf:"varint,4,rep,packed=false,name=opt_out,proto3"`
+	Fixed    []float32 `protobuf:"fixed32,5,rep,packed=true,name=fixed,proto3"`
+	Sint     []int64   `protobuf:"zigzag64,6,rep,name=sint"`
+}
+
+func init() {
+	RegisterTypeFor[packedMessage]()
+}
+
+func TestPackedTags(t *testing.T) {
+	typ := CaptureTypeFor[packedMessage]()
+	want := map[string]bool{"Packed": true, "Unpacked": false, "Default": true, "OptOut": false, "Fixed": true, "Sint": false}
+	for _, field := range typ.Fields {
+		if got := field.Tags.Protobuf.isPacked(); got != want[field.Name] {
+			t.Errorf("%s packed = %v, want %v", field.Name, got, want[field.Name])
+		}
+	}
+}
+
+func TestPackedGolden(t *testing.T) {
+	value := &packedMessage{
+		Packed:   []int32{1, -1, 300},
+		Unpacked: []int32{1, -1, 300},
+		Default:  []uint64{0, 1 << 40},
+		OptOut:   []int32{4, 5},
+		Fixed:    []float32{1.5, -2},
+		Sint:     []int64{-1, 2},
+	}
+	want := decodeHex(t, "0a0d01ffffffffffffffffff01ac02100110ffffffffffffffffff0110ac021a0700808080808020200420052a080000c03f000000c030013004")
+	assertMarshal(t, value, want)
+	assertUnmarshal(t, want, value)
+	assertReadWrite[packedMessage](t, want)
+
+	alternate := decodeHex(t, "080108ffffffffffffffffff01120d01ffffffffffffffffff01ac020a02ac02180018808080808020220204052d0000c03f2d000000c032020104")
+	assertUnmarshal(t, alternate, value)
+	read, err := Read(TypeName(reflect.TypeFor[packedMessage]()), alternate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Write(TypeName(reflect.TypeFor[packedMessage]()), read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Write(Read(%x)) = %x, want %x", alternate, got, want)
+	}
+}
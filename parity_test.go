@@ -0,0 +1,82 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type parityMessage struct {
+	Retries *int32     `protobuf:"varint,1,opt,name=retries,proto3,oneof"`
+	Label   *string    `protobuf:"bytes,2,opt,name=label"`
+	Ratio   *float64   `protobuf:"fixed64,3,opt,name=ratio"`
+	Color   mapColor   `protobuf:"varint,4,opt,name=color,proto3,enum=mapColor"`
+	Colors  []mapColor `protobuf:"varint,5,rep,packed,name=colors,proto3,enum=mapColor"`
+	Wrapped *int64     `protobuf:"bytes,6,opt,name=wrapped,proto3"`
+	Blob    []byte     `protobuf:"bytes,7,opt,name=blob,proto3"`
+	Nested  mapValue   `protobuf:"bytes,8,opt,name=nested,proto3"`
+	Items   []mapValue `protobuf:"bytes,9,rep,name=items,proto3"`
+}
+
+func init() {
+	RegisterTypeFor[parityMessage]()
+}
+
+func TestCompiledParity(t *testing.T) {
+	retries, label, ratio, wrapped := int32(-3), "label", 0.5, int64(0)
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"pointers", &parityMessage{Retries: &retries, Label: &label, Ratio: &ratio, Wrapped: &wrapped}},
+		{"enums", &parityMessage{Color: 2, Colors: []mapColor{0, 1, 2}}},
+		{"values", &parityMessage{Blob: []byte{0, 1}, Nested: mapValue{V: 1}, Items: []mapValue{{V: 2}, {}}}},
+		{"slices", &sizeNode{Children: []*sizeNode{{Name: "a"}, {Numbers: []int64{-1, 1 << 40}}}}},
+		{"maps", &mapMessage{Strings: map[string]string{"k": "v", "": ""}, Bools: map[bool]int32{true: 1}, Sints: map[int32]int64{-1: -2}, Fixed: map[uint64]uint32{1: 2}, Messages: map[string]*mapValue{"m": {V: 3}, "n": {}}, Enums: map[int32]mapColor{1: 2}}},
+		{"oneof", &oneofMessage{Name: "n", Value: &oneofMessage_Child{Child: &oneofChild{V: 1}}}},
+		{"oneof scalar", &oneofMessage{Value: &oneofMessage_Code{Code: -1}}},
+		{"groups", &groupMessage{Single: &groupItem{A: 1, Inner: &groupInner{B: "b"}}, Repeated: []*groupItem{{A: 2}, {Inner: &groupInner{}}}}},
+		{"zigzag", &zigzagMessage{S32: -1, S64: -1 << 40, Packed: []int32{-64, 64}, Keys: map[int64]int32{-2: -3, 5: 6}}},
+		{"packed", &packedMessage{Packed: []int32{1, -1, 300}, Unpacked: []int32{1, -1}, Default: []uint64{1 << 40}, OptOut: []int32{4}, Fixed: []float32{1.5}, Sint: []int64{-1}}},
+	}
+	types := []*Type{
+		CaptureTypeFor[parityMessage](),
+		CaptureTypeFor[mapValue](),
+		CaptureTypeFor[sizeNode](),
+		CaptureTypeFor[mapMessage](),
+		CaptureTypeFor[oneofMessage](),
+		CaptureTypeFor[oneofChild](),
+		CaptureTypeFor[groupMessage](),
+		CaptureTypeFor[groupItem](),
+		CaptureTypeFor[groupInner](),
+		CaptureTypeFor[zigzagMessage](),
+		CaptureTypeFor[packedMessage](),
+	}
+	run := func(value any) ([]byte, any) {
+		t.Helper()
+		data, err := Marshal(value, WithDeterministic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := reflect.New(reflect.TypeOf(value).Elem()).Interface()
+		if err := Unmarshal(data, out); err != nil {
+			t.Fatal(err)
+		}
+		return data, out
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, compiledOut := run(tt.value)
+			t.Run("reflect", func(t *testing.T) {
+				withoutCodecs(t, types...)
+				reflected, reflectedOut := run(tt.value)
+				if !bytes.Equal(compiled, reflected) {
+					t.Fatalf("compiled %x, reflect %x", compiled, reflected)
+				}
+				if !reflect.DeepEqual(compiledOut, reflectedOut) {
+					t.Fatalf("compiled %+v, reflect %+v", compiledOut, reflectedOut)
+				}
+			})
+		})
+	}
+}
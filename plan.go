@@ -0,0 +1,1034 @@
+package protolizer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+type (
+	fieldCodec struct {
+		offset    uintptr
+		unordered bool
+		size      func(p unsafe.Pointer, message reflect.Value, options *MarshalOptions) (int, error)
+		encode    func(out []byte, p unsafe.Pointer, message reflect.Value, options *MarshalOptions) ([]byte, error)
+		decode    func(bytes []byte, pos int, onWire WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error)
+	}
+	scalarCodec struct {
+		wireType WireType
+		zero     func(p unsafe.Pointer) bool
+		size     func(p unsafe.Pointer) int
+		append   func(out []byte, p unsafe.Pointer) []byte
+		decode   func(bytes []byte, pos int, p unsafe.Pointer, options *UnmarshalOptions) (int, error)
+	}
+	messageType struct {
+		t        reflect.Type
+		registry *Registry
+		typ      atomic.Pointer[Type]
+	}
+	signed interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64
+	}
+	unsigned interface {
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+	}
+)
+
+const (
+	maxDenseFieldNumber = 1 << 10
+)
+
+func (t *Type) index() {
+	last := 0
+	for _, f := range t.Fields {
+		last = max(last, f.Tags.Protobuf.FieldNum)
+	}
+	if last < maxDenseFieldNumber {
+		t.byNumber = make([]*Field, last+1)
+		for _, f := range t.Fields {
+			t.byNumber[f.Tags.Protobuf.FieldNum] = f
+		}
+	}
+}
+
+func (t *Type) compile() {
+	generation := _generation.Load()
+	if t.compiled.Load() == generation {
+		return
+	}
+	direct := t.UnknownFields == nil
+	for _, f := range t.Fields {
+		if f.wrapper != nil || len(f.FieldIndex) != 1 {
+			direct = false
+			continue
+		}
+		field := t.reflectType.Field(f.FieldIndex[0])
+		codec := compileField(f, field.Index[0], field.Type, registryOf(t.registry))
+		if codec != nil {
+			codec.offset = field.Offset
+		} else {
+			direct = false
+		}
+		f.codec.Store(codec)
+	}
+	t.direct.Store(direct)
+	t.compiled.Store(generation)
+}
+
+func (t *Type) isDirect() bool {
+	t.compile()
+	return t.direct.Load()
+}
+
+func (t *Type) field(number int) (*Field, bool) {
+	if t.byNumber == nil {
+		field, ok := t.FieldsIndexer[number]
+		return field, ok
+	}
+	if number >= len(t.byNumber) {
+		return nil, false
+	}
+	field := t.byNumber[number]
+	return field, field != nil
+}
+
+func (t *Type) base(reflected reflect.Value) unsafe.Pointer {
+	if t.reflectType != reflected.Type() || !reflected.CanAddr() {
+		return nil
+	}
+	t.compile()
+	return unsafe.Pointer(reflected.UnsafeAddr())
+}
+
+func (mo *MarshalOptions) compiled(f *Field, base unsafe.Pointer) *fieldCodec {
+	if base == nil {
+		return nil
+	}
+	codec := f.codec.Load()
+	if codec == nil || codec.unordered && mo.Deterministic {
+		return nil
+	}
+	return codec
+}
+
+func (uo *UnmarshalOptions) compiled(f *Field, base unsafe.Pointer) *fieldCodec {
+	if base == nil {
+		return nil
+	}
+	return f.codec.Load()
+}
+
+func compileField(f *Field, index int, t reflect.Type, registry *Registry) *fieldCodec {
+	info := f.Tags.Protobuf
+	if info.FieldNum < 1 || info.Wrapper || f.Converted || f.hasDefault() || hasConverter(t) {
+		return nil
+	}
+	tag := appendUvarint(nil, uint64(info.FieldNum)<<3|uint64(f.tagWireType()))
+	switch t.Kind() {
+	case reflect.Pointer:
+		{
+			elem := t.Elem()
+			if elem.Kind() == reflect.Struct {
+				return messageField(tag, index, elem, true, info, registry)
+			}
+			if scalar := newScalarCodec(elem, info); scalar != nil {
+				return pointerField(tag, elem, scalar)
+			}
+		}
+	case reflect.Struct:
+		{
+			return messageField(tag, index, t, false, info, registry)
+		}
+	case reflect.Slice:
+		{
+			elem := t.Elem()
+			if elem.Kind() == reflect.Uint8 {
+				if scalar := newScalarCodec(t, info); scalar != nil {
+					return scalarField(tag, scalar, false)
+				}
+				return nil
+			}
+			if info.Label != "rep" {
+				return nil
+			}
+			if elem.Kind() == reflect.Pointer && elem.Elem().Kind() == reflect.Struct {
+				return repeatedMessageField(tag, index, t, true, info, registry)
+			}
+			if elem.Kind() == reflect.Struct {
+				return repeatedMessageField(tag, index, t, false, info, registry)
+			}
+			if scalar := newScalarCodec(elem, info); scalar != nil {
+				return repeatedField(tag, elem, scalar, info.isPacked())
+			}
+		}
+	case reflect.Map:
+		{
+			key := newScalarCodec(t.Key(), f.Tags.mapKeyInfo())
+			value := newScalarCodec(t.Elem(), f.Tags.mapValueInfo())
+			if key == nil || value == nil {
+				return nil
+			}
+			return mapField(tag, t, key, value)
+		}
+	default:
+		{
+			if scalar := newScalarCodec(t, info); scalar != nil {
+				return scalarField(tag, scalar, f.hasPresence())
+			}
+		}
+	}
+	return nil
+}
+
+func hasConverter(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if converterFor(t) != nil {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		{
+			return t.Elem().Kind() != reflect.Uint8 && hasConverter(t.Elem())
+		}
+	case reflect.Map:
+		{
+			return hasConverter(t.Key()) || hasConverter(t.Elem())
+		}
+	}
+	return false
+}
+
+func scalarField(tag []byte, scalar *scalarCodec, keepZero bool) *fieldCodec {
+	return &fieldCodec{
+		size: func(p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) (int, error) {
+			if !keepZero && scalar.zero(p) {
+				return 0, nil
+			}
+			return len(tag) + scalar.size(p), nil
+		},
+		encode: func(out []byte, p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) ([]byte, error) {
+			if !keepZero && scalar.zero(p) {
+				return out, nil
+			}
+			return scalar.append(append(out, tag...), p), nil
+		},
+		decode: func(bytes []byte, pos int, _ WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			return scalar.decode(bytes, pos, p, options)
+		},
+	}
+}
+
+func pointerField(tag []byte, elem reflect.Type, scalar *scalarCodec) *fieldCodec {
+	return &fieldCodec{
+		size: func(p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) (int, error) {
+			value := *(*unsafe.Pointer)(p)
+			if value == nil {
+				return 0, nil
+			}
+			return len(tag) + scalar.size(value), nil
+		},
+		encode: func(out []byte, p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) ([]byte, error) {
+			value := *(*unsafe.Pointer)(p)
+			if value == nil {
+				return out, nil
+			}
+			return scalar.append(append(out, tag...), value), nil
+		},
+		decode: func(bytes []byte, pos int, _ WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			return scalar.decode(bytes, pos, allocate(p, elem), options)
+		},
+	}
+}
+
+func messageField(tag []byte, index int, elem reflect.Type, pointer bool, info *ProtobufInfo, registry *Registry) *fieldCodec {
+	if elem == timeType || info.WireType != WireTypeLen {
+		return nil
+	}
+	typ := &messageType{t: elem, registry: registry}
+	field := func(p unsafe.Pointer, message reflect.Value) (reflect.Value, bool) {
+		if !pointer {
+			value := message.Field(index)
+			return value, !value.IsZero()
+		}
+		if *(*unsafe.Pointer)(p) == nil {
+			return reflect.Value{}, false
+		}
+		return message.Field(index).Elem(), true
+	}
+	return &fieldCodec{
+		size: func(p unsafe.Pointer, message reflect.Value, options *MarshalOptions) (int, error) {
+			value, ok := field(p, message)
+			if !ok {
+				return 0, nil
+			}
+			n, err := sizeMessage(typ, value, options)
+			return len(tag) + n, err
+		},
+		encode: func(out []byte, p unsafe.Pointer, message reflect.Value, options *MarshalOptions) ([]byte, error) {
+			value, ok := field(p, message)
+			if !ok {
+				return out, nil
+			}
+			return encodeMessage(append(out, tag...), typ, value, options)
+		},
+		decode: func(bytes []byte, pos int, _ WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			if pointer {
+				p = allocate(p, elem)
+			}
+			return decodeMessage(bytes, pos, typ, p, options)
+		},
+	}
+}
+
+func repeatedMessageField(tag []byte, index int, t reflect.Type, pointer bool, info *ProtobufInfo, registry *Registry) *fieldCodec {
+	elem := t.Elem()
+	if pointer {
+		elem = elem.Elem()
+	}
+	if elem == timeType || info.WireType != WireTypeLen {
+		return nil
+	}
+	typ := &messageType{t: elem, registry: registry}
+	item := func(v reflect.Value, i int) (reflect.Value, error) {
+		value := v.Index(i)
+		if !pointer {
+			return value, nil
+		}
+		if value.IsNil() {
+			return reflect.Value{}, fmt.Errorf("unexpected type %v", reflect.Invalid)
+		}
+		return value.Elem(), nil
+	}
+	return &fieldCodec{
+		size: func(p unsafe.Pointer, message reflect.Value, options *MarshalOptions) (int, error) {
+			v := message.Field(index)
+			out := 0
+			for i := range v.Len() {
+				value, err := item(v, i)
+				if err != nil {
+					return 0, err
+				}
+				n, err := sizeMessage(typ, value, options)
+				if err != nil {
+					return 0, err
+				}
+				out += len(tag) + n
+			}
+			return out, nil
+		},
+		encode: func(out []byte, p unsafe.Pointer, message reflect.Value, options *MarshalOptions) ([]byte, error) {
+			v := message.Field(index)
+			for i := range v.Len() {
+				value, err := item(v, i)
+				if err != nil {
+					return nil, err
+				}
+				out, err = encodeMessage(append(out, tag...), typ, value, options)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return out, nil
+		},
+		decode: func(bytes []byte, pos int, _ WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			v := reflect.NewAt(t, p).Elem()
+			if pointer {
+				value := reflect.New(elem)
+				pos, err := decodeMessage(bytes, pos, typ, value.UnsafePointer(), options)
+				if err != nil {
+					return pos, err
+				}
+				v.Set(reflect.Append(v, value))
+				return pos, nil
+			}
+			n := v.Len()
+			v.Grow(1)
+			v.SetLen(n + 1)
+			value := v.Index(n)
+			value.SetZero()
+			return decodeMessage(bytes, pos, typ, unsafe.Pointer(value.UnsafeAddr()), options)
+		},
+	}
+}
+
+func (m *messageType) load() (*Type, error) {
+	if typ := m.typ.Load(); typ != nil {
+		return typ, nil
+	}
+	typ, err := m.registry.lookup(m.t)
+	if err != nil {
+		return nil, err
+	}
+	m.typ.Store(typ)
+	return typ, nil
+}
+
+func sizeMessage(m *messageType, v reflect.Value, options *MarshalOptions) (int, error) {
+	typ, err := m.load()
+	if err != nil {
+		return 0, err
+	}
+	index := options.cache.reserve()
+	n, err := sizeType(typ, v, options)
+	if err != nil {
+		return 0, err
+	}
+	return options.cache.set(index, n), nil
+}
+
+func encodeMessage(out []byte, m *messageType, v reflect.Value, options *MarshalOptions) ([]byte, error) {
+	typ, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	out, start := options.beginLength(out)
+	out, err = marshalType(out, typ, v, options)
+	if err != nil {
+		return nil, err
+	}
+	return options.endLength(out, start), nil
+}
+
+func decodeMessage(bytes []byte, pos int, m *messageType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+	typ, err := m.load()
+	if err != nil {
+		return pos, err
+	}
+	value, consumed, err := viewBytes(bytes, pos)
+	if err != nil {
+		return pos, err
+	}
+	if typ.reflectType == m.t && typ.isDirect() {
+		err = unmarshalDirect(value, typ, p, options)
+	} else {
+		err = unmarshalType(value, typ, reflect.NewAt(m.t, p).Elem(), options)
+	}
+	if err != nil {
+		return pos, err
+	}
+	return pos + consumed, nil
+}
+
+func unmarshalDirect(bytes []byte, typ *Type, base unsafe.Pointer, options *UnmarshalOptions) error {
+	if err := options.enter(); err != nil {
+		return err
+	}
+	defer options.leave()
+	for pos := 0; pos < len(bytes); {
+		fieldNum, wireType, consumed, err := decodeTag(bytes, pos)
+		if err != nil {
+			return err
+		}
+		pos += consumed
+		field, ok := typ.field(int(fieldNum))
+		if !ok {
+			consumed, err := skipValue(bytes, pos, fieldNum, wireType, options)
+			if err != nil {
+				return err
+			}
+			pos += consumed
+			continue
+		}
+		codec := field.codec.Load()
+		pos, err = codec.decode(bytes, pos, wireType, unsafe.Add(base, codec.offset), options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func repeatedField(tag []byte, elem reflect.Type, scalar *scalarCodec, packed bool) *fieldCodec {
+	switch elem.Kind() {
+	case reflect.Int:
+		{
+			return sliceField[int](tag, scalar, packed)
+		}
+	case reflect.Int32:
+		{
+			return sliceField[int32](tag, scalar, packed)
+		}
+	case reflect.Int64:
+		{
+			return sliceField[int64](tag, scalar, packed)
+		}
+	case reflect.Uint:
+		{
+			return sliceField[uint](tag, scalar, packed)
+		}
+	case reflect.Uint32:
+		{
+			return sliceField[uint32](tag, scalar, packed)
+		}
+	case reflect.Uint64:
+		{
+			return sliceField[uint64](tag, scalar, packed)
+		}
+	case reflect.Float32:
+		{
+			return sliceField[float32](tag, scalar, packed)
+		}
+	case reflect.Float64:
+		{
+			return sliceField[float64](tag, scalar, packed)
+		}
+	case reflect.Bool:
+		{
+			return sliceField[bool](tag, scalar, packed)
+		}
+	case reflect.String:
+		{
+			return sliceField[string](tag, scalar, packed)
+		}
+	case reflect.Slice:
+		{
+			return sliceField[[]byte](tag, scalar, packed)
+		}
+	}
+	return nil
+}
+
+func sliceField[E any](tag []byte, scalar *scalarCodec, packed bool) *fieldCodec {
+	payload := func(items []E) int {
+		n := 0
+		for i := range items {
+			n += scalar.size(unsafe.Pointer(&items[i]))
+		}
+		return n
+	}
+	return &fieldCodec{
+		size: func(p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) (int, error) {
+			items := *(*[]E)(p)
+			if len(items) == 0 {
+				return 0, nil
+			}
+			if packed {
+				return len(tag) + sizeBytes(payload(items)), nil
+			}
+			return len(tag)*len(items) + payload(items), nil
+		},
+		encode: func(out []byte, p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) ([]byte, error) {
+			items := *(*[]E)(p)
+			if len(items) == 0 {
+				return out, nil
+			}
+			if packed {
+				out = appendUvarint(append(out, tag...), uint64(payload(items)))
+				for i := range items {
+					out = scalar.append(out, unsafe.Pointer(&items[i]))
+				}
+				return out, nil
+			}
+			for i := range items {
+				out = scalar.append(append(out, tag...), unsafe.Pointer(&items[i]))
+			}
+			return out, nil
+		},
+		decode: func(bytes []byte, pos int, onWire WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			items := (*[]E)(p)
+			var zero E
+			if onWire != WireTypeLen || scalar.wireType == WireTypeLen {
+				*items = append(*items, zero)
+				return scalar.decode(bytes, pos, unsafe.Pointer(&(*items)[len(*items)-1]), options)
+			}
+			value, consumed, err := viewBytes(bytes, pos)
+			if err != nil {
+				return pos, err
+			}
+			for inner := 0; inner < len(value); {
+				*items = append(*items, zero)
+				inner, err = scalar.decode(value, inner, unsafe.Pointer(&(*items)[len(*items)-1]), options)
+				if err != nil {
+					return pos, err
+				}
+			}
+			return pos + consumed, nil
+		},
+	}
+}
+
+func mapField(tag []byte, t reflect.Type, key *scalarCodec, value *scalarCodec) *fieldCodec {
+	switch t.Key() {
+	case reflect.TypeFor[string]():
+		{
+			return mapValueField[string](tag, t.Elem(), key, value)
+		}
+	case reflect.TypeFor[int32]():
+		{
+			return mapValueField[int32](tag, t.Elem(), key, value)
+		}
+	case reflect.TypeFor[int64]():
+		{
+			return mapValueField[int64](tag, t.Elem(), key, value)
+		}
+	case reflect.TypeFor[uint32]():
+		{
+			return mapValueField[uint32](tag, t.Elem(), key, value)
+		}
+	case reflect.TypeFor[uint64]():
+		{
+			return mapValueField[uint64](tag, t.Elem(), key, value)
+		}
+	case reflect.TypeFor[bool]():
+		{
+			return mapValueField[bool](tag, t.Elem(), key, value)
+		}
+	}
+	return nil
+}
+
+func mapValueField[K comparable](tag []byte, t reflect.Type, key *scalarCodec, value *scalarCodec) *fieldCodec {
+	switch t {
+	case reflect.TypeFor[string]():
+		{
+			return mapEntryField[K, string](tag, key, value)
+		}
+	case reflect.TypeFor[[]byte]():
+		{
+			return mapEntryField[K, []byte](tag, key, value)
+		}
+	case reflect.TypeFor[int32]():
+		{
+			return mapEntryField[K, int32](tag, key, value)
+		}
+	case reflect.TypeFor[int64]():
+		{
+			return mapEntryField[K, int64](tag, key, value)
+		}
+	case reflect.TypeFor[uint32]():
+		{
+			return mapEntryField[K, uint32](tag, key, value)
+		}
+	case reflect.TypeFor[uint64]():
+		{
+			return mapEntryField[K, uint64](tag, key, value)
+		}
+	case reflect.TypeFor[float32]():
+		{
+			return mapEntryField[K, float32](tag, key, value)
+		}
+	case reflect.TypeFor[float64]():
+		{
+			return mapEntryField[K, float64](tag, key, value)
+		}
+	case reflect.TypeFor[bool]():
+		{
+			return mapEntryField[K, bool](tag, key, value)
+		}
+	}
+	return nil
+}
+
+func mapEntryField[K comparable, V any](tag []byte, key *scalarCodec, value *scalarCodec) *fieldCodec {
+	type entry struct {
+		key   K
+		value V
+	}
+	size := func(e *entry) int {
+		return 2 + key.size(unsafe.Pointer(&e.key)) + value.size(unsafe.Pointer(&e.value))
+	}
+	return &fieldCodec{
+		unordered: true,
+		size: func(p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) (int, error) {
+			n := 0
+			e := new(entry)
+			for e.key, e.value = range *(*map[K]V)(p) {
+				n += len(tag) + sizeBytes(size(e))
+			}
+			return n, nil
+		},
+		encode: func(out []byte, p unsafe.Pointer, _ reflect.Value, _ *MarshalOptions) ([]byte, error) {
+			e := new(entry)
+			for e.key, e.value = range *(*map[K]V)(p) {
+				out = appendUvarint(append(out, tag...), uint64(size(e)))
+				out = key.append(append(out, 1<<3|byte(key.wireType)), unsafe.Pointer(&e.key))
+				out = value.append(append(out, 2<<3|byte(value.wireType)), unsafe.Pointer(&e.value))
+			}
+			return out, nil
+		},
+		decode: func(bytes []byte, pos int, _ WireType, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			data, consumed, err := viewBytes(bytes, pos)
+			if err != nil {
+				return pos, err
+			}
+			e := new(entry)
+			for inner := 0; inner < len(data); {
+				fieldNum, wireType, n, err := decodeTag(data, inner)
+				if err != nil {
+					return pos, err
+				}
+				inner += n
+				switch fieldNum {
+				case 1:
+					{
+						inner, err = key.decode(data, inner, unsafe.Pointer(&e.key), options)
+					}
+				case 2:
+					{
+						inner, err = value.decode(data, inner, unsafe.Pointer(&e.value), options)
+					}
+				default:
+					{
+						n, err = skipValue(data, inner, fieldNum, wireType, options)
+						inner += n
+					}
+				}
+				if err != nil {
+					return pos, err
+				}
+			}
+			items := (*map[K]V)(p)
+			if *items == nil {
+				*items = make(map[K]V)
+			}
+			(*items)[e.key] = e.value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func newScalarCodec(t reflect.Type, info *ProtobufInfo) *scalarCodec {
+	if info == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Int:
+		{
+			return signedCodec[int](info)
+		}
+	case reflect.Int8:
+		{
+			return signedCodec[int8](info)
+		}
+	case reflect.Int16:
+		{
+			return signedCodec[int16](info)
+		}
+	case reflect.Int32:
+		{
+			return signedCodec[int32](info)
+		}
+	case reflect.Int64:
+		{
+			return signedCodec[int64](info)
+		}
+	case reflect.Uint:
+		{
+			return unsignedCodec[uint](info)
+		}
+	case reflect.Uint8:
+		{
+			return unsignedCodec[uint8](info)
+		}
+	case reflect.Uint16:
+		{
+			return unsignedCodec[uint16](info)
+		}
+	case reflect.Uint32:
+		{
+			return unsignedCodec[uint32](info)
+		}
+	case reflect.Uint64:
+		{
+			return unsignedCodec[uint64](info)
+		}
+	case reflect.Float32:
+		{
+			return float32Codec(info)
+		}
+	case reflect.Float64:
+		{
+			return float64Codec(info)
+		}
+	case reflect.Bool:
+		{
+			return boolCodec(info)
+		}
+	case reflect.String:
+		{
+			if info.WireType != WireTypeLen {
+				return nil
+			}
+			return stringCodec()
+		}
+	case reflect.Slice:
+		{
+			if t.Elem().Kind() != reflect.Uint8 || info.WireType != WireTypeLen {
+				return nil
+			}
+			return bytesCodec()
+		}
+	}
+	return nil
+}
+
+func signedCodec[T signed](info *ProtobufInfo) *scalarCodec {
+	out := &scalarCodec{
+		wireType: info.WireType,
+		zero: func(p unsafe.Pointer) bool {
+			return *(*T)(p) == 0
+		},
+	}
+	switch {
+	case info.WireType == WireTypeI32:
+		{
+			out.size = fixedSize(4)
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendFixed32(b, int32(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeFixed32(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	case info.WireType == WireTypeI64:
+		{
+			out.size = fixedSize(8)
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendFixed64(b, int64(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeFixed64(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	case info.WireType == WireTypeVarint && info.ZigZag:
+		{
+			out.size = func(p unsafe.Pointer) int {
+				value := int64(*(*T)(p))
+				return sizeUvarint(uint64((value << 1) ^ (value >> 63)))
+			}
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendZigZag(b, int64(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeZigZag(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	case info.WireType == WireTypeVarint:
+		{
+			out.size = func(p unsafe.Pointer) int {
+				return sizeUvarint(uint64(*(*T)(p)))
+			}
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendVarint(b, int64(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeVarint(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	default:
+		{
+			return nil
+		}
+	}
+	return out
+}
+
+func unsignedCodec[T unsigned](info *ProtobufInfo) *scalarCodec {
+	out := &scalarCodec{
+		wireType: info.WireType,
+		zero: func(p unsafe.Pointer) bool {
+			return *(*T)(p) == 0
+		},
+	}
+	switch info.WireType {
+	case WireTypeI32:
+		{
+			out.size = fixedSize(4)
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendFixed32(b, int32(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeFixed32(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	case WireTypeI64:
+		{
+			out.size = fixedSize(8)
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendFixed64(b, int64(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeFixed64(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	case WireTypeVarint:
+		{
+			out.size = func(p unsafe.Pointer) int {
+				return sizeUvarint(uint64(*(*T)(p)))
+			}
+			out.append = func(b []byte, p unsafe.Pointer) []byte {
+				return appendUvarint(b, uint64(*(*T)(p)))
+			}
+			out.decode = func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+				value, consumed, err := decodeUvarint(b, pos)
+				if err != nil {
+					return pos, err
+				}
+				*(*T)(p) = T(value)
+				return pos + consumed, nil
+			}
+		}
+	default:
+		{
+			return nil
+		}
+	}
+	return out
+}
+
+func float32Codec(info *ProtobufInfo) *scalarCodec {
+	return &scalarCodec{
+		wireType: info.WireType,
+		zero: func(p unsafe.Pointer) bool {
+			return math.Float32bits(*(*float32)(p)) == 0
+		},
+		size: fixedSize(4),
+		append: func(b []byte, p unsafe.Pointer) []byte {
+			return appendFloat32(b, *(*float32)(p))
+		},
+		decode: func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+			value, consumed, err := decodeFloat32(b, pos)
+			if err != nil {
+				return pos, err
+			}
+			*(*float32)(p) = value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func float64Codec(info *ProtobufInfo) *scalarCodec {
+	return &scalarCodec{
+		wireType: info.WireType,
+		zero: func(p unsafe.Pointer) bool {
+			return math.Float64bits(*(*float64)(p)) == 0
+		},
+		size: fixedSize(8),
+		append: func(b []byte, p unsafe.Pointer) []byte {
+			return appendFloat64(b, *(*float64)(p))
+		},
+		decode: func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+			value, consumed, err := decodeFloat64(b, pos)
+			if err != nil {
+				return pos, err
+			}
+			*(*float64)(p) = value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func boolCodec(info *ProtobufInfo) *scalarCodec {
+	return &scalarCodec{
+		wireType: info.WireType,
+		zero: func(p unsafe.Pointer) bool {
+			return !*(*bool)(p)
+		},
+		size: fixedSize(1),
+		append: func(b []byte, p unsafe.Pointer) []byte {
+			return appendBool(b, *(*bool)(p))
+		},
+		decode: func(b []byte, pos int, p unsafe.Pointer, _ *UnmarshalOptions) (int, error) {
+			value, consumed, err := decodeBool(b, pos)
+			if err != nil {
+				return pos, err
+			}
+			*(*bool)(p) = value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func stringCodec() *scalarCodec {
+	return &scalarCodec{
+		wireType: WireTypeLen,
+		zero: func(p unsafe.Pointer) bool {
+			return len(*(*string)(p)) == 0
+		},
+		size: func(p unsafe.Pointer) int {
+			return sizeBytes(len(*(*string)(p)))
+		},
+		append: func(b []byte, p unsafe.Pointer) []byte {
+			return appendString(b, *(*string)(p))
+		},
+		decode: func(b []byte, pos int, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			value, consumed, err := options.decodeString(b, pos)
+			if err != nil {
+				return pos, err
+			}
+			*(*string)(p) = value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func bytesCodec() *scalarCodec {
+	return &scalarCodec{
+		wireType: WireTypeLen,
+		zero: func(p unsafe.Pointer) bool {
+			return *(*[]byte)(p) == nil
+		},
+		size: func(p unsafe.Pointer) int {
+			return sizeBytes(len(*(*[]byte)(p)))
+		},
+		append: func(b []byte, p unsafe.Pointer) []byte {
+			return appendBytes(b, *(*[]byte)(p))
+		},
+		decode: func(b []byte, pos int, p unsafe.Pointer, options *UnmarshalOptions) (int, error) {
+			value, consumed, err := options.decodeBytes(b, pos)
+			if err != nil {
+				return pos, err
+			}
+			*(*[]byte)(p) = value
+			return pos + consumed, nil
+		},
+	}
+}
+
+func fixedSize(n int) func(unsafe.Pointer) int {
+	return func(unsafe.Pointer) int {
+		return n
+	}
+}
+
+func allocate(p unsafe.Pointer, t reflect.Type) unsafe.Pointer {
+	ptr := (*unsafe.Pointer)(p)
+	if *ptr == nil {
+		*ptr = reflect.New(t).UnsafePointer()
+	}
+	return *ptr
+}
@@ -0,0 +1,71 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type presenceMessage struct {
+	Implicit int32   `protobuf:"varint,1,opt,name=implicit,proto3"`
+	Optional *int32  `protobuf:"varint,2,opt,name=optional,proto3,oneof"`
+	Legacy   *string `protobuf:"bytes,3,opt,name=legacy"`
+	Explicit bool    `protobuf:"varint,4,opt,name=explicit,proto2"`
+	Untagged int32   `protobuf:"varint,5,opt,name=untagged"`
+}
+
+func init() {
+	RegisterTypeFor[presenceMessage]()
+}
+
+func TestPresenceGolden(t *testing.T) {
+	zero, empty := int32(0), ""
+	tests := []struct {
+		name    string
+		value   *presenceMessage
+		want    string
+		present []string
+	}{
+		{"unset", &presenceMessage{}, "2000", []string{"Explicit"}},
+		{"zero values", &presenceMessage{Optional: &zero, Legacy: &empty}, "10001a002000", []string{"Optional", "Legacy", "Explicit"}},
+		{"implicit", &presenceMessage{Implicit: 1, Explicit: true, Untagged: 2}, "080120012802", []string{"Explicit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[presenceMessage](t, want)
+			got, err := Read(TypeName(reflect.TypeFor[presenceMessage]()), want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got[PresentFieldsKey], tt.present) {
+				t.Fatalf("%s = %v, want %v", PresentFieldsKey, got[PresentFieldsKey], tt.present)
+			}
+		})
+	}
+}
+
+func TestPresenceWrite(t *testing.T) {
+	name := TypeName(reflect.TypeFor[presenceMessage]())
+	tests := []struct {
+		name  string
+		value map[string]any
+		want  string
+	}{
+		{"implicit zero", map[string]any{"Implicit": 0, "Untagged": 0}, ""},
+		{"explicit zero", map[string]any{"Optional": 0, "Legacy": "", "Explicit": false}, "10001a002000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Write(name, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Fatalf("Write = %x, want %x", got, want)
+			}
+		})
+	}
+}
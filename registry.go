@@ -0,0 +1,215 @@
+package protolizer
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	Registry struct {
+		mu    sync.Mutex
+		state atomic.Pointer[registryState]
+	}
+	registryState struct {
+		names        map[string]*Type
+		types        map[reflect.Type]*Type
+		enums        map[string]*Enum
+		messageNames map[string]string
+	}
+)
+
+var (
+	_default    *Registry
+	_generation atomic.Int64
+)
+
+func init() {
+	_default = NewRegistry()
+}
+
+func NewRegistry() *Registry {
+	out := new(Registry)
+	out.state.Store(&registryState{
+		names:        make(map[string]*Type),
+		types:        make(map[reflect.Type]*Type),
+		enums:        make(map[string]*Enum),
+		messageNames: make(map[string]string),
+	})
+	out.RegisterType(reflect.TypeFor[Tags]())
+	out.RegisterType(reflect.TypeFor[ProtobufInfo]())
+	out.RegisterType(reflect.TypeFor[Field]())
+	out.RegisterType(reflect.TypeFor[Type]())
+	out.RegisterType(reflect.TypeFor[Enum]())
+	out.RegisterType(reflect.TypeFor[Module]())
+	out.RegisterType(reflect.TypeFor[Any]())
+	return out
+}
+
+func DefaultRegistry() *Registry {
+	return _default
+}
+
+func (r *Registry) RegisterType(t reflect.Type, oneOfWrappers ...any) {
+	out := newType(r, t, oneOfWrappers)
+	r.update(func(state *registryState) {
+		state.add(t, out)
+	})
+}
+
+func (r *Registry) RegisterTypes(types ...reflect.Type) {
+	out := make([]*Type, len(types))
+	for i, t := range types {
+		out[i] = newType(r, t, nil)
+	}
+	r.update(func(state *registryState) {
+		for i, t := range types {
+			state.add(t, out[i])
+		}
+	})
+}
+
+func (r *Registry) RegisterEnum(t reflect.Type, values map[int32]string) {
+	out := newEnum(t, values)
+	r.update(func(state *registryState) {
+		state.enums[out.Name] = out
+	})
+}
+
+func (r *Registry) UnregisterType(t reflect.Type) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.update(func(state *registryState) {
+		typ, ok := state.types[t]
+		if !ok {
+			return
+		}
+		delete(state.types, t)
+		for name, registered := range state.names {
+			if registered == typ {
+				delete(state.names, name)
+			}
+		}
+		for name, typeName := range state.messageNames {
+			if state.names[typeName] == nil {
+				delete(state.messageNames, name)
+			}
+		}
+	})
+}
+
+func (r *Registry) UnregisterEnum(typeName string) {
+	r.update(func(state *registryState) {
+		delete(state.enums, typeName)
+	})
+}
+
+func (r *Registry) CaptureType(t reflect.Type) *Type {
+	state := r.state.Load()
+	if typ, ok := state.types[t]; ok {
+		return typ
+	}
+	return state.names[TypeName(t)]
+}
+
+func (r *Registry) CaptureTypeByName(typeName string) *Type {
+	return r.state.Load().names[typeName]
+}
+
+func (r *Registry) lookup(t reflect.Type) (*Type, error) {
+	typ := r.CaptureType(t)
+	if typ == nil {
+		return nil, fmt.Errorf("type %s is not registered", displayName(t))
+	}
+	return typ, nil
+}
+
+func (r *Registry) lookupByName(typeName string) (*Type, error) {
+	typ := r.CaptureTypeByName(typeName)
+	if typ == nil {
+		return nil, fmt.Errorf("type %s is not registered", typeName)
+	}
+	return typ, nil
+}
+
+func (r *Registry) CaptureEnumByName(typeName string) *Enum {
+	return r.state.Load().enums[typeName]
+}
+
+func (r *Registry) Range(f func(typ *Type) bool) {
+	state := r.state.Load()
+	names := make([]string, 0, len(state.names))
+	for name := range state.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !f(state.names[name]) {
+			return
+		}
+	}
+}
+
+func (r *Registry) RangeEnums(f func(enum *Enum) bool) {
+	state := r.state.Load()
+	names := make([]string, 0, len(state.enums))
+	for name := range state.enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !f(state.enums[name]) {
+			return
+		}
+	}
+}
+
+func (r *Registry) Marshal(v any, opts ...MarshalOption) ([]byte, error) {
+	return Marshal(v, append(opts[:len(opts):len(opts)], WithMarshalRegistry(r))...)
+}
+
+func (r *Registry) Unmarshal(bytes []byte, v any, opts ...UnmarshalOption) error {
+	return Unmarshal(bytes, v, append(opts[:len(opts):len(opts)], WithUnmarshalRegistry(r))...)
+}
+
+func (r *Registry) Read(typeName string, bytes []byte, opts ...UnmarshalOption) (map[string]any, error) {
+	return Read(typeName, bytes, append(opts[:len(opts):len(opts)], WithUnmarshalRegistry(r))...)
+}
+
+func (r *Registry) Write(typeName string, v map[string]any, opts ...MarshalOption) ([]byte, error) {
+	return Write(typeName, v, append(opts[:len(opts):len(opts)], WithMarshalRegistry(r))...)
+}
+
+func (r *Registry) update(f func(state *registryState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.state.Load()
+	next := &registryState{
+		names:        maps.Clone(current.names),
+		types:        maps.Clone(current.types),
+		enums:        maps.Clone(current.enums),
+		messageNames: maps.Clone(current.messageNames),
+	}
+	f(next)
+	r.state.Store(next)
+	_generation.Add(1)
+}
+
+func (s *registryState) add(t reflect.Type, typ *Type) {
+	s.names[TypeName(t)] = typ
+	s.types[typ.reflectType] = typ
+	if name := messageName(typ.reflectType); name != typ.Name {
+		s.messageNames[name] = TypeName(t)
+	}
+}
+
+func registryOf(r *Registry) *Registry {
+	if r == nil {
+		return _default
+	}
+	return r
+}
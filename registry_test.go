@@ -0,0 +1,279 @@
+package protolizer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type (
+	registryInner struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	registryOuter struct {
+		Inner *registryInner            `protobuf:"bytes,1,opt,name=inner,proto3"`
+		Items map[string]*registryInner `protobuf:"bytes,2,rep,name=items,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	}
+)
+
+func TestRegistryUnregistered(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterType(reflect.TypeFor[registryOuter]())
+	outer := TypeName(reflect.TypeFor[registryOuter]())
+	inner := TypeName(reflect.TypeFor[registryInner]())
+	nested := []byte{0x0a, 0x02, 0x08, 0x01}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"Read", func() error { _, err := r.Read("nope", nil); return err }, "nope"},
+		{"Write", func() error { _, err := r.Write("nope", map[string]any{}); return err }, "nope"},
+		{"Marshal", func() error { _, err := r.Marshal(&registryInner{}); return err }, inner},
+		{"Unmarshal", func() error { return r.Unmarshal(nil, &registryInner{}) }, inner},
+		{"Size", func() error { _, err := Size(&struct{ V int32 }{}); return err }, "struct { V int32 }"},
+		{"Marshal nested", func() error { _, err := r.Marshal(&registryOuter{Inner: &registryInner{V: 1}}); return err }, inner},
+		{"Unmarshal nested", func() error { return r.Unmarshal(nested, &registryOuter{}) }, inner},
+		{"Read nested", func() error { _, err := r.Read(outer, nested); return err }, inner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if want := "type " + tt.want + " is not registered"; !strings.Contains(err.Error(), want) {
+				t.Fatalf("got %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestRegistryReadMapOfMessages(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterType(reflect.TypeFor[registryInner]())
+	r.RegisterType(reflect.TypeFor[registryOuter]())
+	outer := TypeName(reflect.TypeFor[registryOuter]())
+
+	got, err := r.Read(outer, []byte{0x12, 0x03, 0x0a, 0x01, 0x6b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"k": map[string]any{}}
+	if !reflect.DeepEqual(got["Items"], want) {
+		t.Fatalf("Items = %#v, want %#v", got["Items"], want)
+	}
+
+	data, err := r.Marshal(&registryOuter{Items: map[string]*registryInner{"k": {V: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out registryOuter
+	if err := r.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Items["k"].V != 1 {
+		t.Fatalf("Items = %+v", out.Items)
+	}
+}
+
+type (
+	registryLevel   int32
+	registryLeveled struct {
+		Level registryLevel `protobuf:"varint,1,opt,name=level,proto3"`
+	}
+)
+
+func TestRegistryUnregister(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterEnum(reflect.TypeFor[registryLevel](), map[int32]string{0: "LOW", 1: "HIGH"})
+	r.RegisterType(reflect.TypeFor[registryLeveled]())
+	name := TypeName(reflect.TypeFor[registryLeveled]())
+	data := []byte{0x08, 0x01}
+
+	got, err := r.Read(name, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["Level"] != "HIGH" {
+		t.Fatalf("Level = %#v, want %q", got["Level"], "HIGH")
+	}
+	r.UnregisterEnum(TypeName(reflect.TypeFor[registryLevel]()))
+	if got, err = r.Read(name, data); err != nil || got["Level"] != float64(1) {
+		t.Fatalf("Read after UnregisterEnum = %#v, %v", got, err)
+	}
+
+	r.UnregisterType(reflect.TypeFor[*registryLeveled]())
+	if r.CaptureType(reflect.TypeFor[registryLeveled]()) != nil || r.CaptureTypeByName(name) != nil {
+		t.Fatal("the type is still registered")
+	}
+	if _, err := r.Marshal(&registryLeveled{}); err == nil {
+		t.Fatal("Marshal succeeded after UnregisterType")
+	}
+	if _, err := r.Read(name, data); err == nil {
+		t.Fatal("Read succeeded after UnregisterType")
+	}
+	r.UnregisterType(reflect.TypeFor[registryLeveled]())
+
+	r.RegisterType(reflect.TypeFor[registryLeveled]())
+	if _, err := r.Marshal(&registryLeveled{Level: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryRange(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterTypes(reflect.TypeFor[registryOuter](), reflect.TypeFor[registryLeveled](), reflect.TypeFor[registryInner]())
+	r.RegisterEnum(reflect.TypeFor[registryLevel](), map[int32]string{0: "LOW"})
+	r.RegisterEnum(reflect.TypeFor[mapColor](), map[int32]string{0: "RED"})
+
+	var names []string
+	r.Range(func(typ *Type) bool {
+		names = append(names, typ.Name)
+		return true
+	})
+	if !slices.IsSorted(names) {
+		t.Fatalf("Range order = %v", names)
+	}
+	for _, typ := range []reflect.Type{reflect.TypeFor[registryOuter](), reflect.TypeFor[registryLeveled](), reflect.TypeFor[registryInner](), reflect.TypeFor[Any]()} {
+		if !slices.Contains(names, TypeName(typ)) {
+			t.Fatalf("Range = %v, missing %s", names, TypeName(typ))
+		}
+	}
+
+	var enums []string
+	r.RangeEnums(func(enum *Enum) bool {
+		enums = append(enums, enum.Name)
+		return true
+	})
+	if want := []string{TypeName(reflect.TypeFor[mapColor]()), TypeName(reflect.TypeFor[registryLevel]())}; !reflect.DeepEqual(enums, want) {
+		t.Fatalf("RangeEnums = %v, want %v", enums, want)
+	}
+
+	count := 0
+	r.Range(func(*Type) bool {
+		count++
+		return false
+	})
+	r.RangeEnums(func(*Enum) bool {
+		count++
+		return false
+	})
+	if count != 2 {
+		t.Fatalf("Range did not stop early: %d calls", count)
+	}
+}
+
+func TestRegisterTypes(t *testing.T) {
+	r := NewRegistry()
+	before := _generation.Load()
+	r.RegisterTypes(reflect.TypeFor[registryInner](), reflect.TypeFor[*registryOuter]())
+	if generations := _generation.Load() - before; generations != 1 {
+		t.Fatalf("RegisterTypes updated the registry %d times", generations)
+	}
+	data, err := r.Marshal(&registryOuter{Inner: &registryInner{V: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out registryOuter
+	if err := r.Unmarshal(data, &out); err != nil || out.Inner.V != 1 {
+		t.Fatalf("Unmarshal = %+v, %v", out, err)
+	}
+}
+
+func TestExportModuleFrom(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterEnum(reflect.TypeFor[registryLevel](), map[int32]string{0: "LOW", 1: "HIGH"})
+	r.RegisterTypes(reflect.TypeFor[registryInner](), reflect.TypeFor[registryOuter](), reflect.TypeFor[registryLeveled]())
+
+	data, err := ExportModuleFrom[registryOuter](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	module, err := ImportModule(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []reflect.Type{reflect.TypeFor[registryOuter](), reflect.TypeFor[registryInner]()} {
+		imported := module.Types[TypeName(typ)]
+		if imported == nil || len(imported.Fields) != len(r.CaptureType(typ).Fields) {
+			t.Fatalf("module is missing %s: %v", TypeName(typ), module.Types)
+		}
+	}
+	if len(module.Types) != 2 || len(module.Enums) != 0 {
+		t.Fatalf("module = %d types and %d enums, want 2 and 0", len(module.Types), len(module.Enums))
+	}
+
+	data, err = ExportModuleFrom[registryLeveled](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module, err = ImportModule(data); err != nil {
+		t.Fatal(err)
+	}
+	enum := module.Enums[TypeName(reflect.TypeFor[registryLevel]())]
+	if enum == nil || enum.Values[1] != "HIGH" {
+		t.Fatalf("module enums = %v", module.Enums)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterTypes(reflect.TypeFor[registryInner](), reflect.TypeFor[registryOuter]())
+	value := &registryOuter{Inner: &registryInner{V: 7}, Items: map[string]*registryInner{"k": {V: 1}}}
+	want, err := r.Marshal(value, WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := TypeName(reflect.TypeFor[registryOuter]())
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				data, err := r.Marshal(value, WithDeterministic())
+				if err != nil || !bytes.Equal(data, want) {
+					t.Errorf("Marshal = %x, %v", data, err)
+					return
+				}
+				var out registryOuter
+				if err := r.Unmarshal(data, &out); err != nil || out.Inner.V != 7 {
+					t.Errorf("Unmarshal = %+v, %v", out, err)
+					return
+				}
+				if _, err := r.Read(name, data); err != nil {
+					t.Errorf("Read: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		typ := reflect.StructOf([]reflect.StructField{{
+			Name: "V",
+			Type: reflect.TypeFor[int32](),
+			Tag:  reflect.StructTag(fmt.Sprintf(`protobuf:"varint,%d,opt,name=v,proto3"`, i%100+1)),
+		}})
+		r.RegisterType(typ)
+		r.RegisterEnum(reflect.TypeFor[registryLevel](), map[int32]string{int32(i): "LEVEL"})
+		r.Range(func(*Type) bool { return true })
+		r.UnregisterType(typ)
+	}
+}
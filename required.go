@@ -0,0 +1,108 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type RequiredNotSetError struct {
+	Fields []string
+}
+
+func (e *RequiredNotSetError) Error() string {
+	return fmt.Sprintf("required fields not set: %s", strings.Join(e.Fields, ", "))
+}
+
+func checkRequired(typ *Type, reflected reflect.Value) error {
+	if typ == nil || !hasRequired(typ) {
+		return nil
+	}
+	missing := missingRequired(registryOf(typ.registry), reflected, "", make([]string, 0))
+	if len(missing) != 0 {
+		return &RequiredNotSetError{Fields: missing}
+	}
+	return nil
+}
+
+func missingRequired(registry *Registry, reflected reflect.Value, path string, missing []string) []string {
+	typ := registry.CaptureType(reflected.Type())
+	if typ == nil || !hasRequired(typ) {
+		return missing
+	}
+	for _, i := range typ.Fields {
+		v := reflected.FieldByIndex(i.FieldIndex)
+		if len(i.OneOf) != 0 {
+			v = i.oneOfCase(v)
+		}
+		name := path + i.Name
+		if !i.isPresent(v) {
+			if i.Tags.Protobuf.Label == "req" {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		switch i.Kind {
+		case reflect.Struct:
+			{
+				missing = missingRequired(registry, reflect.Indirect(v), name+".", missing)
+			}
+		case reflect.Array, reflect.Slice:
+			{
+				if i.Index != reflect.Struct {
+					continue
+				}
+				for j := 0; j < v.Len(); j++ {
+					elem := reflect.Indirect(v.Index(j))
+					if !elem.IsValid() {
+						continue
+					}
+					missing = missingRequired(registry, elem, fmt.Sprintf("%s[%d].", name, j), missing)
+				}
+			}
+		case reflect.Map:
+			{
+				if i.Index != reflect.Struct {
+					continue
+				}
+				keys := v.MapKeys()
+				sortMapKeys(keys)
+				for _, key := range keys {
+					elem := reflect.Indirect(v.MapIndex(key))
+					if !elem.IsValid() {
+						continue
+					}
+					missing = missingRequired(registry, elem, fmt.Sprintf("%s[%v].", name, key), missing)
+				}
+			}
+		}
+	}
+	return missing
+}
+
+func hasRequired(typ *Type) bool {
+	generation := _generation.Load()
+	if cached := typ.required.Load(); cached>>1 == generation {
+		return cached&1 == 1
+	}
+	out := hasRequiredFields(typ, make(map[*Type]bool))
+	if out {
+		typ.required.Store(generation<<1 | 1)
+	} else {
+		typ.required.Store(generation << 1)
+	}
+	return out
+}
+
+func hasRequiredFields(typ *Type, visited map[*Type]bool) bool {
+	if typ == nil || visited[typ] {
+		return false
+	}
+	visited[typ] = true
+	for _, field := range typ.Fields {
+		if field.Tags.Protobuf.Label == "req" || hasRequiredFields(registryOf(typ.registry).CaptureTypeByName(elemTypeName(field)), visited) {
+			return true
+		}
+	}
+	return false
+}
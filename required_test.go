@@ -0,0 +1,87 @@
+package protolizer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type (
+	requiredItem struct {
+		Sku *string `protobuf:"bytes,1,req,name=sku"`
+	}
+	requiredOrder struct {
+		Customer *string                  `protobuf:"bytes,1,req,name=customer"`
+		Item     *requiredItem            `protobuf:"bytes,2,opt,name=item"`
+		Items    []*requiredItem          `protobuf:"bytes,3,rep,name=items"`
+		Lookup   map[string]*requiredItem `protobuf:"bytes,4,rep,name=lookup" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	}
+)
+
+func init() {
+	RegisterTypeFor[requiredItem]()
+	RegisterTypeFor[requiredOrder]()
+}
+
+func TestRequiredMarshal(t *testing.T) {
+	customer, sku := "c", "s"
+	tests := []struct {
+		name    string
+		value   *requiredOrder
+		missing []string
+	}{
+		{"complete", &requiredOrder{Customer: &customer, Item: &requiredItem{Sku: &sku}}, nil},
+		{"top level", &requiredOrder{}, []string{"Customer"}},
+		{"nested", &requiredOrder{Customer: &customer, Item: &requiredItem{}}, []string{"Item.Sku"}},
+		{"repeated", &requiredOrder{Customer: &customer, Items: []*requiredItem{{Sku: &sku}, {}}}, []string{"Items[1].Sku"}},
+		{"map", &requiredOrder{Customer: &customer, Lookup: map[string]*requiredItem{"b": {}, "a": {}}}, []string{"Lookup[a].Sku", "Lookup[b].Sku"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.value)
+			assertMissing(t, err, tt.missing)
+			if _, err := Marshal(tt.value, WithMarshalAllowPartial()); err != nil {
+				t.Fatalf("Marshal with WithMarshalAllowPartial: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequiredUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		missing []string
+	}{
+		{"complete", "0a016312030a0173", nil},
+		{"top level", "", []string{"Customer"}},
+		{"nested", "0a01631200", []string{"Item.Sku"}},
+		{"repeated", "0a01631a00", []string{"Items[0].Sku"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := decodeHex(t, tt.data)
+			assertMissing(t, Unmarshal(data, new(requiredOrder)), tt.missing)
+			if err := Unmarshal(data, new(requiredOrder), WithUnmarshalAllowPartial()); err != nil {
+				t.Fatalf("Unmarshal with WithUnmarshalAllowPartial: %v", err)
+			}
+		})
+	}
+}
+
+func assertMissing(t *testing.T, err error, missing []string) {
+	t.Helper()
+	if missing == nil {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	var required *RequiredNotSetError
+	if !errors.As(err, &required) {
+		t.Fatalf("got %v, want a *RequiredNotSetError", err)
+	}
+	if !reflect.DeepEqual(required.Fields, missing) {
+		t.Fatalf("missing %v, want %v", required.Fields, missing)
+	}
+}
@@ -0,0 +1,340 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+type (
+	sizeCache struct {
+		sizes []int
+		keys  [][]reflect.Value
+		data  [][]byte
+		size  int
+		key   int
+		datum int
+	}
+)
+
+func Size(v any) (int, error) {
+	reflected := reflect.ValueOf(v)
+	if reflected.Kind() == reflect.Pointer {
+		reflected = reflected.Elem()
+	}
+	options := acquireMarshalOptions(nil)
+	defer options.release()
+	return size(reflected, options)
+}
+
+func size(reflected reflect.Value, options *MarshalOptions) (int, error) {
+	typ, err := options.registry().lookup(reflected.Type())
+	if err != nil {
+		return 0, err
+	}
+	return sizeType(typ, reflected, options)
+}
+
+func sizeType(typ *Type, reflected reflect.Value, options *MarshalOptions) (int, error) {
+	if err := options.enter(); err != nil {
+		return 0, err
+	}
+	defer options.leave()
+	base := typ.base(reflected)
+	out := 0
+	for _, i := range typ.Fields {
+		if codec := options.compiled(i, base); codec != nil {
+			n, err := codec.size(unsafe.Add(base, codec.offset), reflected, options)
+			if err != nil {
+				return 0, err
+			}
+			out += n
+			continue
+		}
+		var opts []codecOption
+		v := reflected.FieldByIndex(i.FieldIndex)
+		if len(i.OneOf) != 0 {
+			v = i.oneOfCase(v)
+		}
+		if !i.isPresent(v) {
+			continue
+		}
+		if v.Kind() == reflect.Map && !i.Tags.Protobuf.isStruct(i.Key, i.Index) {
+			opts = append(opts, withMapInfo(i.Tags.mapKeyInfo(), i.Tags.mapValueInfo()))
+		}
+		if v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		n, err := sizeValue(v, i.Kind, i.Tags.Protobuf, options, opts...)
+		if err != nil {
+			return 0, err
+		}
+		out += sizeTag(i.Tags.Protobuf.FieldNum) + n
+	}
+	if typ.UnknownFields != nil {
+		out += reflected.FieldByIndex(typ.UnknownFields).Len()
+	}
+	return out, nil
+}
+
+func sizeValue(v reflect.Value, kind reflect.Kind, info *ProtobufInfo, options *MarshalOptions, opts ...codecOption) (int, error) {
+	fieldNumber, wireType := info.FieldNum, info.WireType
+	if info.Wrapper {
+		return sizeWrapper(v, kind, options)
+	}
+	if wireType == WireTypeLen {
+		if conv := converterFor(v.Type()); conv != nil {
+			return sizeConverted(v, conv, options)
+		}
+	}
+	switch kind {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			if wireType == WireTypeLen && v.Type() == durationType {
+				return sizeDuration(time.Duration(v.Int())), nil
+			}
+			if wireType == WireTypeI32 {
+				return 4, nil
+			}
+			if wireType == WireTypeI64 {
+				return 8, nil
+			}
+			if info.ZigZag {
+				return sizeUvarint(uint64((v.Int() << 1) ^ (v.Int() >> 63))), nil
+			}
+			return sizeUvarint(uint64(v.Int())), nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			if wireType == WireTypeI32 {
+				return 4, nil
+			}
+			if wireType == WireTypeI64 {
+				return 8, nil
+			}
+			return sizeUvarint(v.Uint()), nil
+		}
+	case reflect.Float32:
+		{
+			return 4, nil
+		}
+	case reflect.Float64:
+		{
+			return 8, nil
+		}
+	case reflect.Bool:
+		{
+			return 1, nil
+		}
+	case reflect.String:
+		{
+			return sizeBytes(v.Len()), nil
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			k := v.Type().Elem().Kind()
+			if info.isListValue(k) {
+				index := options.cache.reserve()
+				n, err := sizeListValue(v, options)
+				if err != nil {
+					return 0, err
+				}
+				return options.cache.set(index, n), nil
+			}
+			if k == reflect.Uint8 {
+				return sizeBytes(v.Len()), nil
+			}
+			if info.isPacked() {
+				index := options.cache.reserve()
+				n := 0
+				for i := 0; i < v.Len(); i++ {
+					v := v.Index(i)
+					if v.Kind() == reflect.Pointer {
+						v = v.Elem()
+					}
+					size, err := sizeValue(v, v.Kind(), info, options)
+					if err != nil {
+						return 0, err
+					}
+					n += size
+				}
+				return options.cache.set(index, n), nil
+			}
+			n := 0
+			elemInfo := info.elementInfo(elementKinds(v.Type().Elem()))
+			for i := 0; i < v.Len(); i++ {
+				if i != 0 {
+					n += sizeTag(fieldNumber)
+				}
+				v := v.Index(i)
+				if v.Kind() == reflect.Pointer {
+					v = v.Elem()
+				}
+				size, err := sizeValue(v, v.Kind(), elemInfo, options)
+				if err != nil {
+					return 0, err
+				}
+				n += size
+			}
+			return n, nil
+		}
+	case reflect.Map:
+		{
+			if info.isStruct(v.Type().Key().Kind(), v.Type().Elem().Kind()) {
+				index := options.cache.reserve()
+				n, err := sizeStruct(v, options)
+				if err != nil {
+					return 0, err
+				}
+				return options.cache.set(index, n), nil
+			}
+			codecOptions := new(codecOptions)
+			for _, opt := range opts {
+				opt(codecOptions)
+			}
+			keys := options.cache.pushKeys(v, options.Deterministic)
+			n := 0
+			for i, key := range keys {
+				if i != 0 {
+					n += sizeTag(fieldNumber)
+				}
+				value := v.MapIndex(key)
+				if key.Kind() == reflect.Pointer {
+					key = key.Elem()
+				}
+				index := options.cache.reserve()
+				keySize, err := sizeValue(key, key.Kind(), codecOptions.MapKeyInfo, options)
+				if err != nil {
+					return 0, err
+				}
+				if value.Kind() == reflect.Pointer {
+					if value.IsNil() {
+						value = reflect.New(value.Type().Elem())
+					}
+					value = value.Elem()
+				}
+				valueSize, err := sizeValue(value, value.Kind(), codecOptions.MapValueInfo, options)
+				if err != nil {
+					return 0, err
+				}
+				n += options.cache.set(index, sizeTag(1)+keySize+sizeTag(2)+valueSize)
+			}
+			return n, nil
+		}
+	case reflect.Struct:
+		{
+			if v.Type() == timeType {
+				return sizeTimestamp(v.Interface().(time.Time))
+			}
+			if wireType == WireTypeSGroup {
+				n, err := size(v, options)
+				if err != nil {
+					return 0, err
+				}
+				return n + sizeTag(fieldNumber), nil
+			}
+			index := options.cache.reserve()
+			n, err := size(v, options)
+			if err != nil {
+				return 0, err
+			}
+			return options.cache.set(index, n), nil
+		}
+	case reflect.Interface:
+		{
+			index := options.cache.reserve()
+			n, err := sizeStructValue(v, options)
+			if err != nil {
+				return 0, err
+			}
+			return options.cache.set(index, n), nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected type %v", kind)
+}
+
+func sizeTag(fieldNumber int) int {
+	return sizeUvarint(uint64(fieldNumber) << 3)
+}
+
+func sizeBytes(length int) int {
+	return sizeUvarint(uint64(length)) + length
+}
+
+func (c *sizeCache) reset() {
+	clear(c.keys)
+	clear(c.data)
+	c.sizes, c.keys, c.data = c.sizes[:0], c.keys[:0], c.data[:0]
+	c.size, c.key, c.datum = 0, 0, 0
+}
+
+func (c *sizeCache) reserve() int {
+	c.sizes = append(c.sizes, 0)
+	return len(c.sizes) - 1
+}
+
+func (c *sizeCache) set(index int, size int) int {
+	c.sizes[index] = size
+	return sizeBytes(size)
+}
+
+func (c *sizeCache) pushKeys(v reflect.Value, deterministic bool) []reflect.Value {
+	keys := v.MapKeys()
+	if deterministic {
+		sortMapKeys(keys)
+	}
+	c.keys = append(c.keys, keys)
+	return keys
+}
+
+func (c *sizeCache) pushData(data []byte) {
+	c.data = append(c.data, data)
+}
+
+func (c *sizeCache) nextSize() int {
+	c.size++
+	return c.sizes[c.size-1]
+}
+
+func (c *sizeCache) nextKeys() []reflect.Value {
+	c.key++
+	return c.keys[c.key-1]
+}
+
+func (c *sizeCache) nextData() []byte {
+	c.datum++
+	return c.data[c.datum-1]
+}
+
+func (mo *MarshalOptions) beginLength(out []byte) ([]byte, int) {
+	if mo.cache == nil {
+		return out, len(out)
+	}
+	return appendUvarint(out, uint64(mo.cache.nextSize())), -1
+}
+
+func (mo *MarshalOptions) endLength(out []byte, start int) []byte {
+	if start < 0 {
+		return out
+	}
+	return prefixLength(out, start)
+}
+
+func (mo *MarshalOptions) converted(v reflect.Value, conv *converter) ([]byte, error) {
+	if mo.cache != nil {
+		return mo.cache.nextData(), nil
+	}
+	return conv.marshal(v)
+}
+
+func (mo *MarshalOptions) mapKeys(v reflect.Value) []reflect.Value {
+	if mo.cache != nil {
+		return mo.cache.nextKeys()
+	}
+	keys := v.MapKeys()
+	if mo.Deterministic {
+		sortMapKeys(keys)
+	}
+	return keys
+}
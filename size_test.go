@@ -0,0 +1,70 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type sizeNode struct {
+	Name     string               `protobuf:"bytes,1,opt,name=name,proto3"`
+	Left     *sizeNode            `protobuf:"bytes,2,opt,name=left,proto3"`
+	Children []*sizeNode          `protobuf:"bytes,3,rep,name=children,proto3"`
+	Index    map[string]*sizeNode `protobuf:"bytes,4,rep,name=index,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Right    *sizeNode            `protobuf:"bytes,5,opt,name=right,proto3"`
+	Numbers  []int64              `protobuf:"varint,6,rep,packed,name=numbers,proto3"`
+}
+
+func init() {
+	RegisterTypeFor[sizeNode]()
+}
+
+func TestSizeMatchesMarshal(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 200))
+	leaf := func(name string) *sizeNode {
+		return &sizeNode{Name: name, Numbers: []int64{-1, 1 << 40}}
+	}
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"empty", &sizeNode{}},
+		{"nested", &sizeNode{Name: "root", Left: &sizeNode{Left: &sizeNode{Name: long}}, Right: leaf("right")}},
+		{"siblings", &sizeNode{Left: leaf(long), Children: []*sizeNode{leaf("a"), {Children: []*sizeNode{leaf(long), leaf("b")}}, {}}, Right: &sizeNode{Left: leaf("c")}}},
+		{"map of messages", &sizeNode{Index: map[string]*sizeNode{"a": leaf(long), "b": {Index: map[string]*sizeNode{"c": leaf("c"), "d": nil}}, "e": {}}, Right: leaf("right")}},
+		{"packed", &packedMessage{Packed: []int32{1, -1, 300}, Unpacked: []int32{1, -1}, Default: []uint64{1 << 40}, OptOut: []int32{4}, Fixed: []float32{1.5}, Sint: []int64{-1}}},
+		{"zigzag", &zigzagMessage{S32: -1, S64: -1 << 40, Packed: []int32{-64, 64}, Keys: map[int64]int32{-2: -3, 5: 6}}},
+		{"groups", &groupMessage{Single: &groupItem{A: 1, Inner: &groupInner{B: long}}, Repeated: []*groupItem{{A: 2}, {Inner: &groupInner{}}}}},
+		{"oneof", &oneofMessage{Name: "n", Value: &oneofMessage_Child{Child: &oneofChild{V: 1}}}},
+		{"maps", &mapMessage{Strings: map[string]string{"k": long, "": ""}, Messages: map[string]*mapValue{"m": {V: 3}, "n": {}}, Enums: map[int32]mapColor{1: 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, value := range []any{tt.value, reflect.ValueOf(tt.value).Elem().Interface()} {
+				data, err := Marshal(value, WithDeterministic())
+				if err != nil {
+					t.Fatal(err)
+				}
+				size, err := Size(value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if size != len(data) {
+					t.Fatalf("Size(%T) = %d, len(Marshal) = %d", value, size, len(data))
+				}
+				for _, opts := range [][]MarshalOption{nil, {WithDeterministic()}} {
+					out, err := MarshalAppend([]byte("prefix"), value, opts...)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.HasPrefix(out, []byte("prefix")) || len(out)-len("prefix") != size {
+						t.Fatalf("MarshalAppend(%T) = %x, want the prefix and %d more bytes", value, out, size)
+					}
+					if len(opts) != 0 && !bytes.Equal(out[len("prefix"):], data) {
+						t.Fatalf("MarshalAppend(%T) = %x, want %x", value, out[len("prefix"):], data)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package protolizer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"iter"
+)
+
+type (
+	Encoder struct {
+		w    io.Writer
+		buf  []byte
+		opts []MarshalOption
+	}
+	Decoder struct {
+		r        *bufio.Reader
+		buf      []byte
+		maxSize  int
+		alias    bool
+		registry *Registry
+		opts     []UnmarshalOption
+	}
+)
+
+const (
+	DefaultMaxMessageSize = 64 << 20
+	maxVarintSize         = 10
+)
+
+func NewEncoder(w io.Writer, opts ...MarshalOption) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+func (e *Encoder) Encode(v any) error {
+	if cap(e.buf) < maxVarintSize {
+		e.buf = make([]byte, maxVarintSize, 512)
+	}
+	out, err := MarshalAppend(e.buf[:maxVarintSize], v, e.opts...)
+	if err != nil {
+		return err
+	}
+	e.buf = out
+	length := uint64(len(out) - maxVarintSize)
+	start := maxVarintSize - sizeUvarint(length)
+	appendUvarint(out[start:start], length)
+	_, err = e.w.Write(out[start:])
+	return err
+}
+
+func NewDecoder(r io.Reader, opts ...UnmarshalOption) *Decoder {
+	options := newUnmarshalOptions(opts)
+	return &Decoder{
+		r:        bufio.NewReader(r),
+		maxSize:  DefaultMaxMessageSize,
+		alias:    options.AliasBytes || options.AliasStrings,
+		registry: options.registry(),
+		opts:     opts,
+	}
+}
+
+func (d *Decoder) SetMaxSize(n int) {
+	if n <= 0 {
+		n = DefaultMaxMessageSize
+	}
+	d.maxSize = n
+}
+
+func (d *Decoder) Decode(v any) error {
+	data, err := d.next()
+	if err != nil {
+		return err
+	}
+	return Unmarshal(data, v, d.opts...)
+}
+
+func (d *Decoder) next() ([]byte, error) {
+	length, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(d.maxSize) {
+		return nil, fmt.Errorf("message of %d bytes exceeds maximum size of %d", length, d.maxSize)
+	}
+	data := d.buf
+	if d.alias || cap(data) < int(length) {
+		data = make([]byte, length)
+	}
+	data = data[:length]
+	if !d.alias {
+		d.buf = data
+	}
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeAll[T any](d *Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		codec, err := CodecFrom[T](d.registry)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for {
+			var out T
+			data, err := d.next()
+			if err == io.EOF {
+				return
+			}
+			if err == nil {
+				err = codec.Unmarshal(data, &out, d.opts...)
+			}
+			if !yield(out, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package protolizer
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoderMaxSize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(&_person); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	dec := NewDecoder(bytes.NewReader(data))
+	dec.SetMaxSize(1)
+	if err := dec.Decode(&Person{}); err == nil {
+		t.Fatal("Decode succeeded above the size limit")
+	}
+
+	for _, n := range []int{0, -1} {
+		dec := NewDecoder(bytes.NewReader(data))
+		dec.SetMaxSize(n)
+		if dec.maxSize != DefaultMaxMessageSize {
+			t.Fatalf("SetMaxSize(%d) left limit %d", n, dec.maxSize)
+		}
+		var out Person
+		if err := dec.Decode(&out); err != nil {
+			t.Fatalf("SetMaxSize(%d): %v", n, err)
+		}
+		if out != _person {
+			t.Fatalf("SetMaxSize(%d) decoded %+v", n, out)
+		}
+	}
+}
+
+func encodeStream(t *testing.T, values ...any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	people := []Person{_person, {}, {Name: strings.Repeat("n", 300), Age: 7}}
+	data := encodeStream(t, &people[0], &people[1], &people[2])
+	readers := map[string]func([]byte) io.Reader{
+		"whole":    func(b []byte) io.Reader { return bytes.NewReader(b) },
+		"one byte": func(b []byte) io.Reader { return iotest.OneByteReader(bytes.NewReader(b)) },
+		"half":     func(b []byte) io.Reader { return iotest.HalfReader(bytes.NewReader(b)) },
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(reader(data))
+			for i, want := range people {
+				var got Person
+				if err := dec.Decode(&got); err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				if got != want {
+					t.Fatalf("message %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if err := dec.Decode(&Person{}); err != io.EOF {
+				t.Fatalf("Decode after the last message = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	data := encodeStream(t, &_contact, &_contact)
+	second := len(data) / 2
+	long := encodeStream(t, &Contact{Phones: []string{strings.Repeat("n", 300)}})
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, io.EOF},
+		{"between messages", data[:second], io.EOF},
+		{"inside a body", data[:len(data)-1], io.ErrUnexpectedEOF},
+		{"after a length", data[:second+1], io.ErrUnexpectedEOF},
+		{"inside a length", long[:1], io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewReader(tt.data))
+			var err error
+			for err == nil {
+				err = dec.Decode(&Contact{})
+			}
+			if err != tt.want {
+				t.Fatalf("Decode = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	people := []Person{_person, {}, {Name: "last"}}
+	data := encodeStream(t, &people[0], &people[1], &people[2])
+	for _, opts := range [][]UnmarshalOption{nil, {WithAliasStrings()}} {
+		var got []Person
+		for person, err := range DecodeAll[Person](NewDecoder(bytes.NewReader(data), opts...)) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, person)
+		}
+		if !reflect.DeepEqual(got, people) {
+			t.Fatalf("DecodeAll = %+v, want %+v", got, people)
+		}
+	}
+
+	var errs []error
+	for _, err := range DecodeAll[Person](NewDecoder(bytes.NewReader(data[:len(data)-1]))) {
+		errs = append(errs, err)
+	}
+	if len(errs) != len(people) || errs[0] != nil || errs[len(errs)-1] != io.ErrUnexpectedEOF {
+		t.Fatalf("DecodeAll of a truncated stream yielded %v", errs)
+	}
+
+	count := 0
+	for range DecodeAll[Person](NewDecoder(bytes.NewReader(data))) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Fatalf("DecodeAll kept yielding after break: %d", count)
+	}
+
+	type unregistered struct {
+		V int32 `protobuf:"varint,1,opt,name=v,proto3"`
+	}
+	for _, err := range DecodeAll[unregistered](NewDecoder(bytes.NewReader(data))) {
+		if err == nil {
+			t.Fatal("DecodeAll of an unregistered type did not fail")
+		}
+	}
+}
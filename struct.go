@@ -0,0 +1,396 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func (p *ProtobufInfo) isStruct(key reflect.Kind, index reflect.Kind) bool {
+	return p.Label != "rep" && key == reflect.String && index == reflect.Interface
+}
+
+func (p *ProtobufInfo) isListValue(index reflect.Kind) bool {
+	return p.Label != "rep" && index == reflect.Interface
+}
+
+func (p *ProtobufInfo) elementInfo(kind reflect.Kind, key reflect.Kind, index reflect.Kind) *ProtobufInfo {
+	if p.Label != "rep" || index != reflect.Interface || (kind != reflect.Slice && (kind != reflect.Map || key != reflect.String)) {
+		return p
+	}
+	out := *p
+	out.Label = "opt"
+	return &out
+}
+
+func elementKinds(t reflect.Type) (reflect.Kind, reflect.Kind, reflect.Kind) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		{
+			return t.Kind(), t.Key().Kind(), t.Elem().Kind()
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			return t.Kind(), reflect.Invalid, t.Elem().Kind()
+		}
+	}
+	return t.Kind(), reflect.Invalid, reflect.Invalid
+}
+
+func appendField(out []byte, fieldNumber int32, wireType WireType, value []byte) []byte {
+	tag, _ := encodeTag(fieldNumber, wireType)
+	return append(append(out, tag...), value...)
+}
+
+func structValueOf(value any, t reflect.Type) reflect.Value {
+	if value == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(value)
+}
+
+func encodeStructValue(out []byte, v reflect.Value, options *MarshalOptions) ([]byte, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		out, _ = appendTag(out, 1, WireTypeVarint)
+		return appendVarint(out, 0), nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			out, _ = appendTag(out, 2, WireTypeI64)
+			return appendFloat64(out, float64(v.Int())), nil
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8:
+		{
+			out, _ = appendTag(out, 2, WireTypeI64)
+			return appendFloat64(out, float64(v.Uint())), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		{
+			out, _ = appendTag(out, 2, WireTypeI64)
+			return appendFloat64(out, v.Float()), nil
+		}
+	case reflect.String:
+		{
+			out, _ = appendTag(out, 3, WireTypeLen)
+			return appendString(out, v.String()), nil
+		}
+	case reflect.Bool:
+		{
+			out, _ = appendTag(out, 4, WireTypeVarint)
+			return appendBool(out, v.Bool()), nil
+		}
+	case reflect.Map:
+		{
+			if v.Type().Key().Kind() != reflect.String {
+				break
+			}
+			out, _ = appendTag(out, 5, WireTypeLen)
+			out, start := options.beginLength(out)
+			out, err := encodeStruct(out, v, options)
+			if err != nil {
+				return nil, err
+			}
+			return options.endLength(out, start), nil
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				break
+			}
+			out, _ = appendTag(out, 6, WireTypeLen)
+			out, start := options.beginLength(out)
+			out, err := encodeListValue(out, v, options)
+			if err != nil {
+				return nil, err
+			}
+			return options.endLength(out, start), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported struct value type %v", v.Type())
+}
+
+func encodeStruct(out []byte, v reflect.Value, options *MarshalOptions) ([]byte, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	for _, key := range options.mapKeys(v) {
+		var entry, value int
+		out, _ = appendTag(out, 1, WireTypeLen)
+		out, entry = options.beginLength(out)
+		out, _ = appendTag(out, 1, WireTypeLen)
+		out = appendString(out, key.String())
+		out, _ = appendTag(out, 2, WireTypeLen)
+		out, value = options.beginLength(out)
+		var err error
+		out, err = encodeStructValue(out, v.MapIndex(key), options)
+		if err != nil {
+			return nil, err
+		}
+		out = options.endLength(options.endLength(out, value), entry)
+	}
+	return out, nil
+}
+
+func encodeListValue(out []byte, v reflect.Value, options *MarshalOptions) ([]byte, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	for i := 0; i < v.Len(); i++ {
+		var start int
+		out, _ = appendTag(out, 1, WireTypeLen)
+		out, start = options.beginLength(out)
+		var err error
+		out, err = encodeStructValue(out, v.Index(i), options)
+		if err != nil {
+			return nil, err
+		}
+		out = options.endLength(out, start)
+	}
+	return out, nil
+}
+
+func sizeStructValue(v reflect.Value, options *MarshalOptions) (int, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return sizeTag(1) + 1, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8, reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint8, reflect.Float32, reflect.Float64:
+		{
+			return sizeTag(2) + 8, nil
+		}
+	case reflect.String:
+		{
+			return sizeTag(3) + sizeBytes(v.Len()), nil
+		}
+	case reflect.Bool:
+		{
+			return sizeTag(4) + 1, nil
+		}
+	case reflect.Map:
+		{
+			if v.Type().Key().Kind() != reflect.String {
+				break
+			}
+			index := options.cache.reserve()
+			n, err := sizeStruct(v, options)
+			if err != nil {
+				return 0, err
+			}
+			return sizeTag(5) + options.cache.set(index, n), nil
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				break
+			}
+			index := options.cache.reserve()
+			n, err := sizeListValue(v, options)
+			if err != nil {
+				return 0, err
+			}
+			return sizeTag(6) + options.cache.set(index, n), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported struct value type %v", v.Type())
+}
+
+func sizeStruct(v reflect.Value, options *MarshalOptions) (int, error) {
+	if err := options.enter(); err != nil {
+		return 0, err
+	}
+	defer options.leave()
+	out := 0
+	for _, key := range options.cache.pushKeys(v, options.Deterministic) {
+		entry := options.cache.reserve()
+		value := options.cache.reserve()
+		n, err := sizeStructValue(v.MapIndex(key), options)
+		if err != nil {
+			return 0, err
+		}
+		n = sizeTag(1) + sizeBytes(key.Len()) + sizeTag(2) + options.cache.set(value, n)
+		out += sizeTag(1) + options.cache.set(entry, n)
+	}
+	return out, nil
+}
+
+func sizeListValue(v reflect.Value, options *MarshalOptions) (int, error) {
+	if err := options.enter(); err != nil {
+		return 0, err
+	}
+	defer options.leave()
+	out := 0
+	for i := 0; i < v.Len(); i++ {
+		index := options.cache.reserve()
+		n, err := sizeStructValue(v.Index(i), options)
+		if err != nil {
+			return 0, err
+		}
+		out += sizeTag(1) + options.cache.set(index, n)
+	}
+	return out, nil
+}
+
+func decodeStructValue(data []byte, options *UnmarshalOptions) (any, error) {
+	var out any
+	pos := 0
+	for pos < len(data) {
+		fieldNum, wireType, consumed, err := decodeTag(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+		switch {
+		case fieldNum == 1 && wireType == WireTypeVarint:
+			{
+				_, consumed, err = decodeVarint(data, pos)
+				out = nil
+			}
+		case fieldNum == 2 && wireType == WireTypeI64:
+			{
+				out, consumed, err = decodeFloat64(data, pos)
+			}
+		case fieldNum == 3 && wireType == WireTypeLen:
+			{
+				out, consumed, err = options.decodeString(data, pos)
+			}
+		case fieldNum == 4 && wireType == WireTypeVarint:
+			{
+				out, consumed, err = decodeBool(data, pos)
+			}
+		case fieldNum == 5 && wireType == WireTypeLen:
+			{
+				var value []byte
+				value, consumed, err = viewBytes(data, pos)
+				if err == nil {
+					out, err = decodeStruct(value, options)
+				}
+			}
+		case fieldNum == 6 && wireType == WireTypeLen:
+			{
+				var value []byte
+				value, consumed, err = viewBytes(data, pos)
+				if err == nil {
+					out, err = decodeListValue(value, options)
+				}
+			}
+		default:
+			{
+				consumed, err = skipValue(data, pos, fieldNum, wireType, options)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+	}
+	return out, nil
+}
+
+func decodeStruct(data []byte, options *UnmarshalOptions) (map[string]any, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	out := make(map[string]any)
+	pos := 0
+	for pos < len(data) {
+		fieldNum, wireType, consumed, err := decodeTag(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+		if fieldNum != 1 || wireType != WireTypeLen {
+			consumed, err := skipValue(data, pos, fieldNum, wireType, options)
+			if err != nil {
+				return nil, err
+			}
+			pos += consumed
+			continue
+		}
+		entry, consumed, err := viewBytes(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+		var key string
+		var value any
+		innerPos := 0
+		for innerPos < len(entry) {
+			fieldNum, wireType, consumed, err := decodeTag(entry, innerPos)
+			if err != nil {
+				return nil, err
+			}
+			innerPos += consumed
+			switch {
+			case fieldNum == 1 && wireType == WireTypeLen:
+				{
+					key, consumed, err = options.decodeString(entry, innerPos)
+				}
+			case fieldNum == 2 && wireType == WireTypeLen:
+				{
+					var bytes []byte
+					bytes, consumed, err = viewBytes(entry, innerPos)
+					if err == nil {
+						value, err = decodeStructValue(bytes, options)
+					}
+				}
+			default:
+				{
+					consumed, err = skipValue(entry, innerPos, fieldNum, wireType, options)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			innerPos += consumed
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+func decodeListValue(data []byte, options *UnmarshalOptions) ([]any, error) {
+	if err := options.enter(); err != nil {
+		return nil, err
+	}
+	defer options.leave()
+	out := make([]any, 0)
+	pos := 0
+	for pos < len(data) {
+		fieldNum, wireType, consumed, err := decodeTag(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+		if fieldNum != 1 || wireType != WireTypeLen {
+			consumed, err := skipValue(data, pos, fieldNum, wireType, options)
+			if err != nil {
+				return nil, err
+			}
+			pos += consumed
+			continue
+		}
+		bytes, consumed, err := viewBytes(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos += consumed
+		value, err := decodeStructValue(bytes, options)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
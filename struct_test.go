@@ -0,0 +1,117 @@
+package protolizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type structMessage struct {
+	Metadata map[string]any            `protobuf:"bytes,1,opt,name=metadata,proto3"`
+	Tags     []any                     `protobuf:"bytes,2,opt,name=tags,proto3"`
+	Value    any                       `protobuf:"bytes,3,opt,name=value,proto3"`
+	Values   []any                     `protobuf:"bytes,4,rep,name=values,proto3"`
+	Records  []map[string]any          `protobuf:"bytes,5,rep,name=records,proto3"`
+	Lists    [][]any                   `protobuf:"bytes,6,rep,name=lists,proto3"`
+	Lookup   map[string]map[string]any `protobuf:"bytes,7,rep,name=lookup,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Fields   map[string]any            `protobuf:"bytes,8,rep,name=fields,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func init() {
+	RegisterTypeFor[structMessage]()
+}
+
+func TestStructGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *structMessage
+		want  string
+		read  map[string]any
+	}{
+		{
+			"struct",
+			&structMessage{Metadata: map[string]any{"a": 1.5, "b": nil, "c": []any{"x", true, []any{2.0}}, "d": map[string]any{"e": "f"}}},
+			"0a4d0a0e0a0161120911000000000000f83f0a070a0162120208000a1f0a0163121a32180a031a01780a0220010a0d320b0a091100000000000000400a110a0164120c2a0a0a080a016512031a0166",
+			map[string]any{"Metadata": map[string]any{"a": 1.5, "b": nil, "c": []any{"x", true, []any{2.0}}, "d": map[string]any{"e": "f"}}},
+		},
+		{
+			"list",
+			&structMessage{Tags: []any{nil, 1.0, "s", []any{[]any{}}}},
+			"121c0a0208000a0911000000000000f03f0a031a01730a0632040a023200",
+			map[string]any{"Tags": []any{nil, 1.0, "s", []any{[]any{}}}},
+		},
+		{
+			"value",
+			&structMessage{Value: map[string]any{"k": false}},
+			"1a0b2a090a070a016b12022000",
+			map[string]any{"Value": map[string]any{"k": false}},
+		},
+		{
+			"repeated value",
+			&structMessage{Values: []any{nil, "v"}},
+			"2202080022031a0176",
+			map[string]any{"Values": []any{nil, "v"}},
+		},
+		{
+			"repeated struct",
+			&structMessage{Records: []map[string]any{{"a": 1.0}, {}, {"b": []any{"c"}}}},
+			"2a100a0e0a0161120911000000000000f03f2a002a0e0a0c0a0162120732050a031a0163",
+			map[string]any{"Records": []any{map[string]any{"a": 1.0}, map[string]any{}, map[string]any{"b": []any{"c"}}}},
+		},
+		{
+			"repeated list",
+			&structMessage{Lists: [][]any{{1.0, nil}, {}}},
+			"320f0a0911000000000000f03f0a0208003200",
+			map[string]any{"Lists": []any{[]any{1.0, nil}, []any{}}},
+		},
+		{
+			"map of structs",
+			&structMessage{Lookup: map[string]map[string]any{"x": {"n": 2.0}, "y": {}}},
+			"3a150a017812100a0e0a016e12091100000000000000403a050a01791200",
+			map[string]any{"Lookup": map[string]any{"x": map[string]any{"n": 2.0}, "y": map[string]any{}}},
+		},
+		{
+			"map of values",
+			&structMessage{Fields: map[string]any{"k": nil, "v": true}},
+			"42070a016b1202080042070a017612022001",
+			map[string]any{"Fields": map[string]any{"k": nil, "v": true}},
+		},
+	}
+	name := TypeName(reflect.TypeFor[structMessage]())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			if n, err := Size(tt.value); err != nil || n != len(want) {
+				t.Fatalf("Size = %d, %v, want %d", n, err, len(want))
+			}
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[structMessage](t, want)
+			got, err := Read(name, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(got, PresentFieldsKey)
+			if !reflect.DeepEqual(got, tt.read) {
+				t.Fatalf("Read = %#v, want %#v", got, tt.read)
+			}
+		})
+	}
+}
+
+func TestStructNull(t *testing.T) {
+	data := decodeHex(t, "1a020800")
+	got := &structMessage{Value: "stale"}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Value != nil {
+		t.Fatalf("Value = %#v, want nil", got.Value)
+	}
+	read, err := Read(TypeName(reflect.TypeFor[structMessage]()), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := read["Value"]; !ok || value != nil {
+		t.Fatalf("Read = %#v, want a nil Value", read)
+	}
+}
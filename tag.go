@@ -3,6 +3,10 @@ package protolizer
 import "fmt"
 
 func encodeTag(fieldNumber int32, wireType WireType) ([]byte, error) {
+	return appendTag(nil, fieldNumber, wireType)
+}
+
+func appendTag(out []byte, fieldNumber int32, wireType WireType) ([]byte, error) {
 	if fieldNumber < 1 {
 		return nil, fmt.Errorf("field number must be positive")
 	}
@@ -11,7 +15,7 @@ func encodeTag(fieldNumber int32, wireType WireType) ([]byte, error) {
 	}
 
 	tag := (int64(fieldNumber) << 3) | int64(wireType)
-	return encodeVarint(tag), nil
+	return appendVarint(out, tag), nil
 }
 
 func decodeTag(data []byte, offset int) (int32, WireType, int, error) {
@@ -29,3 +33,48 @@ func decodeTag(data []byte, offset int) (int32, WireType, int, error) {
 
 	return fieldNumber, wireType, consumed, nil
 }
+
+func skipValue(data []byte, offset int, fieldNumber int32, wireType WireType, options *UnmarshalOptions) (int, error) {
+	switch wireType {
+	case WireTypeVarint:
+		{
+			_, consumed, err := decodeUvarint(data, offset)
+			return consumed, err
+		}
+	case WireTypeI64:
+		{
+			if len(data) < offset+8 {
+				return 0, fmt.Errorf("insufficient bytes for fixed64")
+			}
+			return 8, nil
+		}
+	case WireTypeLen:
+		{
+			length, lengthSize, err := decodeUvarint(data, offset)
+			if err != nil {
+				return 0, err
+			}
+			if uint64(len(data)-offset-lengthSize) < length {
+				return 0, fmt.Errorf("insufficient bytes for length-prefixed data")
+			}
+			return lengthSize + int(length), nil
+		}
+	case WireTypeSGroup:
+		{
+			_, consumed, err := decodeGroup(data, offset, fieldNumber, options)
+			return consumed, err
+		}
+	case WireTypeEGroup:
+		{
+			return 0, fmt.Errorf("unexpected end group for field %d", fieldNumber)
+		}
+	case WireTypeI32:
+		{
+			if len(data) < offset+4 {
+				return 0, fmt.Errorf("insufficient bytes for fixed32")
+			}
+			return 4, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid wire type %d", wireType)
+}
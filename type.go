@@ -6,15 +6,19 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type (
-	WireType uint8
-	Tags     struct {
-		Protobuf *ProtobufInfo `protobuf:"bytes,1,opt,name=protobuf,proto3"`
-		JsonName string        `protobuf:"bytes,2,opt,name=json_name,proto3"`
-		MapKey   WireType      `protobuf:"varint,3,opt,name=map_key,proto3,enum"`
-		MapValue WireType      `protobuf:"varint,4,opt,name=map_value,proto3,enum"`
+	WireType      uint8
+	UnknownFields []byte
+	Tags          struct {
+		Protobuf     *ProtobufInfo `protobuf:"bytes,1,opt,name=protobuf,proto3"`
+		JsonName     string        `protobuf:"bytes,2,opt,name=json_name,proto3"`
+		MapKey       WireType      `protobuf:"varint,3,opt,name=map_key,proto3,enum"`
+		MapValue     WireType      `protobuf:"varint,4,opt,name=map_value,proto3,enum"`
+		MapKeyInfo   *ProtobufInfo `protobuf:"bytes,5,opt,name=map_key_info,proto3"`
+		MapValueInfo *ProtobufInfo `protobuf:"bytes,6,opt,name=map_value_info,proto3"`
 	}
 
 	ProtobufInfo struct {
@@ -24,29 +28,61 @@ type (
 		Name     string   `protobuf:"bytes,4,opt,name=name,proto3"`
 		Syntax   string   `protobuf:"bytes,5,opt,name=syntax,proto3"`
 		OneOf    bool     `protobuf:"varint,6,opt,name=one_of,proto3"`
+		ZigZag   bool     `protobuf:"varint,7,opt,name=zig_zag,proto3"`
+		Optional bool     `protobuf:"varint,8,opt,name=optional,proto3"`
+		Enum     bool     `protobuf:"varint,9,opt,name=enum,proto3"`
+		Packed   bool     `protobuf:"varint,10,opt,name=packed,proto3"`
+		Default  string   `protobuf:"bytes,11,opt,name=default,proto3"`
+		Wrapper  bool     `protobuf:"varint,12,opt,name=wrapper,proto3"`
 	}
 
 	Field struct {
-		Name       string       `protobuf:"bytes,1,opt,name=name,proto3"`
-		Kind       reflect.Kind `protobuf:"varint,2,opt,name=kind,proto3,enum"`
-		Key        reflect.Kind `protobuf:"varint,3,opt,name=key,proto3,enum"`
-		Index      reflect.Kind `protobuf:"varint,4,opt,name=index,proto3"`
-		KeyType    string       `protobuf:"bytes,5,opt,name=key_type,proto3,enum"`
-		IndexType  string       `protobuf:"bytes,6,opt,name=index_type,proto3,enum"`
-		FieldIndex []int        `protobuf:"varint,7,rep,packed,name=field_index,proto3"`
-		IsPointer  bool         `protobuf:"varint,8,opt,name=is_pointer,proto3"`
-		TypeName   string       `protobuf:"bytes,9,opt,name=type_name,proto3"`
-		Tags       *Tags        `protobuf:"bytes,10,opt,name=tags,proto3"`
+		Name           string       `protobuf:"bytes,1,opt,name=name,proto3"`
+		Kind           reflect.Kind `protobuf:"varint,2,opt,name=kind,proto3,enum"`
+		Key            reflect.Kind `protobuf:"varint,3,opt,name=key,proto3,enum"`
+		Index          reflect.Kind `protobuf:"varint,4,opt,name=index,proto3"`
+		KeyType        string       `protobuf:"bytes,5,opt,name=key_type,proto3,enum"`
+		IndexType      string       `protobuf:"bytes,6,opt,name=index_type,proto3,enum"`
+		FieldIndex     []int        `protobuf:"varint,7,rep,packed,name=field_index,proto3"`
+		IsPointer      bool         `protobuf:"varint,8,opt,name=is_pointer,proto3"`
+		TypeName       string       `protobuf:"bytes,9,opt,name=type_name,proto3"`
+		Tags           *Tags        `protobuf:"bytes,10,opt,name=tags,proto3"`
+		OneOf          string       `protobuf:"bytes,11,opt,name=one_of,proto3"`
+		Enum           string       `protobuf:"bytes,12,opt,name=enum,proto3"`
+		Length         int          `protobuf:"varint,13,opt,name=length,proto3"`
+		Converted      bool         `protobuf:"varint,14,opt,name=converted,proto3"`
+		IndexConverted bool         `protobuf:"varint,15,opt,name=index_converted,proto3"`
+
+		wrapper   reflect.Type
+		registry  *Registry
+		codec     atomic.Pointer[fieldCodec]
+		elemKey   reflect.Kind
+		elemIndex reflect.Kind
 	}
 
 	Type struct {
 		Name          string         `protobuf:"bytes,1,opt,name=fields,proto3"`
 		Fields        []*Field       `protobuf:"bytes,2,rep,name=fields,proto3"`
 		FieldsIndexer map[int]*Field `protobuf:"bytes,3,rep,name=fields_indexer,proto3" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+		UnknownFields []int          `protobuf:"varint,4,rep,packed,name=unknown_fields,proto3"`
+
+		reflectType reflect.Type
+		registry    *Registry
+		byNumber    []*Field
+		compiled    atomic.Int64
+		direct      atomic.Bool
+		required    atomic.Int64
+	}
+
+	Enum struct {
+		Name    string           `protobuf:"bytes,1,opt,name=name,proto3"`
+		Values  map[int32]string `protobuf:"bytes,2,rep,name=values,proto3" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+		Numbers map[string]int32 `protobuf:"bytes,3,rep,name=numbers,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
 	}
 
 	Module struct {
 		Types map[string]*Type `protobuf:"bytes,1,rep,name=types,proto3" protobuf_key:"string,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+		Enums map[string]*Enum `protobuf:"bytes,2,rep,name=enums,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	}
 )
 
@@ -59,37 +95,43 @@ const (
 	WireTypeI32    WireType = 5
 )
 
-var (
-	_registry map[string]*Type
+const (
+	UnknownFieldsKey = "@unknown"
+	PresentFieldsKey = "@present"
 )
 
-func init() {
-	_registry = make(map[string]*Type)
-	RegisterTypeFor[Tags]()
-	RegisterTypeFor[ProtobufInfo]()
-	RegisterTypeFor[Field]()
-	RegisterTypeFor[Type]()
-	RegisterTypeFor[Module]()
+func RegisterTypeFor[T any](oneOfWrappers ...any) {
+	_default.RegisterType(reflect.TypeFor[T](), oneOfWrappers...)
 }
 
-func RegisterTypeFor[T any]() {
+func RegisterTypes(types ...reflect.Type) {
+	_default.RegisterTypes(types...)
+}
+
+func newType(registry *Registry, t reflect.Type, oneOfWrappers []any) *Type {
 	out := new(Type)
 
-	t := reflect.TypeFor[T]()
 	elemType := t
 	if t.Kind() == reflect.Ptr {
 		elemType = t.Elem()
 	}
 
 	out.Name = TypeName(elemType)
+	out.reflectType = elemType
+	out.registry = registry
 	out.Fields = make([]*Field, 0)
 	for i := range elemType.NumField() {
-		f := newField(elemType.Field(i))
+		if elemType.Field(i).Type == reflect.TypeFor[UnknownFields]() {
+			out.UnknownFields = elemType.Field(i).Index
+			continue
+		}
+		f := newField(registry, elemType.Field(i))
 		if !f.Tags.isProtobuf() {
 			continue
 		}
 		out.Fields = append(out.Fields, f)
 	}
+	out.Fields = append(out.Fields, newOneOfFields(registry, elemType, oneOfWrappers)...)
 	sort.Slice(out.Fields, func(i, j int) bool {
 		return out.Fields[i].Tags.Protobuf.FieldNum < out.Fields[j].Tags.Protobuf.FieldNum
 	})
@@ -98,29 +140,30 @@ func RegisterTypeFor[T any]() {
 	for _, i := range out.Fields {
 		out.FieldsIndexer[i.Tags.Protobuf.FieldNum] = i
 	}
-
-	_registry[TypeName(t)] = out
+	out.index()
+	return out
 }
 
 func TypeName(t reflect.Type) string {
-	return fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+	return t.PkgPath() + "." + t.Name()
 }
 
 func CaptureTypeFor[T any]() *Type {
-	return _registry[TypeName(reflect.TypeFor[T]())]
+	return CaptureType(reflect.TypeFor[T]())
 }
 
 func CaptureType(t reflect.Type) *Type {
-	return _registry[TypeName(t)]
+	return _default.CaptureType(t)
 }
 
 func CaptureTypeByName(typeName string) *Type {
-	return _registry[typeName]
+	return _default.CaptureTypeByName(typeName)
 }
 
-func newField(f reflect.StructField) *Field {
+func newField(registry *Registry, f reflect.StructField) *Field {
 	out := new(Field)
 	out.Name = f.Name
+	out.registry = registry
 	out.Kind = f.Type.Kind()
 
 	if f.Type.Kind() == reflect.Ptr {
@@ -132,12 +175,22 @@ func newField(f reflect.StructField) *Field {
 	switch out.Kind {
 	case reflect.Array, reflect.Slice:
 		{
-			elem := f.Type.Elem()
+			t := f.Type
+			if out.IsPointer {
+				t = t.Elem()
+			}
+			elem := t.Elem()
 			if elem.Kind() == reflect.Pointer {
 				elem = elem.Elem()
 			}
 			out.Index = elem.Kind()
 			out.IndexType = TypeName(elem)
+			out.Enum = enumTypeName(registry, elem, out.Tags.Protobuf)
+			out.IndexConverted = isConverted(elem, out.Tags.Protobuf)
+			_, out.elemKey, out.elemIndex = elementKinds(elem)
+			if out.Kind == reflect.Array {
+				out.Length = t.Len()
+			}
 		}
 	case reflect.Map:
 		{
@@ -149,13 +202,59 @@ func newField(f reflect.StructField) *Field {
 			out.KeyType = TypeName(f.Type.Key())
 			out.Index = elem.Kind()
 			out.IndexType = TypeName(elem)
+			out.Enum = enumTypeName(registry, elem, out.Tags.MapValueInfo)
+			out.IndexConverted = isConverted(elem, out.Tags.mapValueInfo())
+			_, out.elemKey, out.elemIndex = elementKinds(elem)
 		}
 	}
+	out.Converted = isConverted(f.Type, out.Tags.Protobuf)
+	if isWrapper(f, out) {
+		out.Tags.Protobuf.Wrapper = true
+	}
 	if out.IsPointer {
 		out.TypeName = TypeName(f.Type.Elem())
-		return out
+		out.Enum = enumTypeName(registry, f.Type.Elem(), out.Tags.Protobuf)
+	} else {
+		out.TypeName = TypeName(f.Type)
+		if len(out.Enum) == 0 {
+			out.Enum = enumTypeName(registry, f.Type, out.Tags.Protobuf)
+		}
+	}
+	if out.Tags.isProtobuf() && out.hasDefault() {
+		if _, err := parseDefault(out, out.Tags.Protobuf.Default); err != nil {
+			panic(err)
+		}
+	}
+	return out
+}
+
+func newOneOfFields(registry *Registry, t reflect.Type, wrappers []any) []*Field {
+	if method := reflect.New(t).MethodByName("XXX_OneofWrappers"); method.IsValid() {
+		if generated, ok := method.Call(nil)[0].Interface().([]any); ok {
+			wrappers = append(wrappers, generated...)
+		}
+	}
+	out := make([]*Field, 0)
+	for _, wrapper := range wrappers {
+		wrapperType := reflect.TypeOf(wrapper)
+		if wrapperType.Kind() != reflect.Pointer || wrapperType.Elem().Kind() != reflect.Struct || wrapperType.Elem().NumField() != 1 {
+			panic(fmt.Errorf("invalid oneof wrapper %v: expected a pointer to a single field struct", wrapperType))
+		}
+		for i := range t.NumField() {
+			group := t.Field(i)
+			if _, ok := group.Tag.Lookup("protobuf_oneof"); !ok || group.Type.Kind() != reflect.Interface || !wrapperType.Implements(group.Type) {
+				continue
+			}
+			f := newField(registry, wrapperType.Elem().Field(0))
+			if !f.Tags.isProtobuf() {
+				panic(fmt.Errorf("invalid oneof wrapper %v: missing protobuf tag", wrapperType))
+			}
+			f.FieldIndex = group.Index
+			f.OneOf = group.Name
+			f.wrapper = wrapperType
+			out = append(out, f)
+		}
 	}
-	out.TypeName = TypeName(f.Type)
 	return out
 }
 
@@ -165,10 +264,12 @@ func newTags(t reflect.StructTag) *Tags {
 		out.Protobuf = parseProtoTag(tag)
 	}
 	if tag, ok := t.Lookup("protobuf_key"); ok {
-		out.MapKey = parseProtoTag(tag).WireType
+		out.MapKeyInfo = parseProtoTag(tag)
+		out.MapKey = out.MapKeyInfo.WireType
 	}
 	if tag, ok := t.Lookup("protobuf_val"); ok {
-		out.MapValue = parseProtoTag(tag).WireType
+		out.MapValueInfo = parseProtoTag(tag)
+		out.MapValue = out.MapValueInfo.WireType
 	}
 	out.JsonName = t.Get("json")
 	return out
@@ -176,7 +277,7 @@ func newTags(t reflect.StructTag) *Tags {
 
 func getWireType(str string) WireType {
 	switch str {
-	case "varint":
+	case "varint", "zigzag32", "zigzag64":
 		{
 			return WireTypeVarint
 		}
@@ -188,7 +289,7 @@ func getWireType(str string) WireType {
 		{
 			return WireTypeLen
 		}
-	case "start_group":
+	case "start_group", "group":
 		{
 			return WireTypeSGroup
 		}
@@ -224,8 +325,10 @@ func parseProtoTag(tag string) *ProtobufInfo {
 	}
 
 	out := new(ProtobufInfo)
+	unpacked := false
 	if l > 0 {
 		out.WireType = getWireType(segments[0])
+		out.ZigZag = strings.HasPrefix(segments[0], "zigzag")
 	}
 	if l > 1 {
 		out.FieldNum = fieldNum
@@ -233,14 +336,46 @@ func parseProtoTag(tag string) *ProtobufInfo {
 	if l > 2 {
 		out.Label = segments[2]
 	}
-	if l > 3 {
-		out.Name = strings.TrimPrefix(segments[3], "name=")
-	}
-	if l > 4 {
-		out.Syntax = segments[4]
+	for i := min(l, 3); i < l; i++ {
+		segment := segments[i]
+		switch {
+		case strings.HasPrefix(segment, "name="):
+			{
+				out.Name = strings.TrimPrefix(segment, "name=")
+			}
+		case segment == "proto2", segment == "proto3":
+			{
+				out.Syntax = segment
+			}
+		case segment == "proto3_optional":
+			{
+				out.Optional = true
+			}
+		case segment == "oneof":
+			{
+				out.OneOf = true
+			}
+		case segment == "enum", strings.HasPrefix(segment, "enum="):
+			{
+				out.Enum = true
+			}
+		case segment == "packed", segment == "packed=true":
+			{
+				out.Packed = true
+			}
+		case segment == "packed=false":
+			{
+				unpacked = true
+			}
+		case strings.HasPrefix(segment, "def="):
+			{
+				out.Default = strings.TrimPrefix(strings.Join(segments[i:], ","), "def=")
+				i = l
+			}
+		}
 	}
-	if len(segments) == 6 {
-		out.OneOf = true
+	if out.Syntax == "proto3" && out.Label == "rep" && !unpacked {
+		out.Packed = true
 	}
 
 	return out
@@ -250,6 +385,95 @@ func (t *Tags) isProtobuf() bool {
 	return t.Protobuf != nil
 }
 
+func (f *Field) hasPresence() bool {
+	info := f.Tags.Protobuf
+	switch f.Kind {
+	case reflect.Map:
+		{
+			return false
+		}
+	case reflect.Array, reflect.Slice:
+		{
+			if info.Label == "rep" || f.Index != reflect.Uint8 {
+				return false
+			}
+		}
+	case reflect.Struct:
+		{
+			return true
+		}
+	}
+	return f.IsPointer || info.Wrapper || info.Optional || info.OneOf || info.Label == "req" || info.Syntax == "proto2" || f.hasDefault()
+}
+
+func (f *Field) isPresent(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if (v.Kind() == reflect.Slice && f.Index != reflect.Uint8 || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return false
+	}
+	if !v.IsZero() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Struct:
+		{
+			return false
+		}
+	}
+	return f.hasPresence()
+}
+
+func (f *Field) oneOfCase(v reflect.Value) reflect.Value {
+	if v.IsNil() || v.Elem().Type() != f.wrapper {
+		return reflect.Value{}
+	}
+	return v.Elem().Elem().Field(0)
+}
+
+func (f *Field) setOneOfCase(v reflect.Value) reflect.Value {
+	if v.IsNil() || v.Elem().Type() != f.wrapper {
+		v.Set(reflect.New(f.wrapper.Elem()))
+	}
+	return v.Elem().Elem().Field(0)
+}
+
+func (f *Field) tagWireType() WireType {
+	if (f.Kind == reflect.Array || f.Kind == reflect.Slice) && f.Tags.Protobuf.isPacked() {
+		return WireTypeLen
+	}
+	return f.Tags.Protobuf.WireType
+}
+
+func (p *ProtobufInfo) isScalar() bool {
+	switch p.WireType {
+	case WireTypeVarint, WireTypeI32, WireTypeI64:
+		{
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ProtobufInfo) isPacked() bool {
+	return p.Packed && p.isScalar()
+}
+
+func (t *Tags) mapKeyInfo() *ProtobufInfo {
+	if t.MapKeyInfo != nil {
+		return t.MapKeyInfo
+	}
+	return &ProtobufInfo{WireType: t.MapKey, FieldNum: 1}
+}
+
+func (t *Tags) mapValueInfo() *ProtobufInfo {
+	if t.MapValueInfo != nil {
+		return t.MapValueInfo
+	}
+	return &ProtobufInfo{WireType: t.MapValue, FieldNum: 2}
+}
+
 func ExportType[T any]() ([]byte, error) {
 	t := CaptureTypeFor[T]()
 	return Marshal(t)
@@ -263,26 +487,43 @@ func ImportType(bytes []byte) (*Type, error) {
 	return t, nil
 }
 
-func exportModule(t reflect.Type) (*Module, error) {
+func exportModule(registry *Registry, t reflect.Type) (*Module, error) {
 	module := new(Module)
 	module.Types = make(map[string]*Type)
-	module.Types[TypeName(t)] = CaptureType(t)
+	module.Enums = make(map[string]*Enum)
+	module.Types[TypeName(t)] = registry.CaptureType(t)
+	fieldTypes := make([]reflect.Type, 0)
 	for i := range t.NumField() {
-		fieldType := t.Field(i).Type
+		fieldTypes = append(fieldTypes, t.Field(i).Type)
+	}
+	if typ := module.Types[TypeName(t)]; typ != nil {
+		for _, field := range typ.Fields {
+			if field.wrapper != nil {
+				fieldTypes = append(fieldTypes, field.wrapper.Elem().Field(0).Type)
+			}
+			if enum := registry.CaptureEnumByName(field.Enum); enum != nil {
+				module.Enums[enum.Name] = enum
+			}
+		}
+	}
+	for _, fieldType := range fieldTypes {
 		if fieldType.Kind() == reflect.Array || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Map {
 			fieldType = fieldType.Elem()
 		}
 		if fieldType.Kind() == reflect.Pointer {
 			fieldType = fieldType.Elem()
 		}
-		if fieldType.Kind() == reflect.Struct {
-			modules, err := exportModule(fieldType)
+		if fieldType.Kind() == reflect.Struct && !isWellKnown(fieldType) && converterFor(fieldType) == nil {
+			modules, err := exportModule(registry, fieldType)
 			if err != nil {
 				return nil, err
 			}
 			for key, value := range modules.Types {
 				module.Types[key] = value
 			}
+			for key, value := range modules.Enums {
+				module.Enums[key] = value
+			}
 			continue
 		}
 	}
@@ -290,7 +531,11 @@ func exportModule(t reflect.Type) (*Module, error) {
 }
 
 func ExportModule[T any]() ([]byte, error) {
-	modules, err := exportModule(reflect.TypeFor[T]())
+	return ExportModuleFrom[T](_default)
+}
+
+func ExportModuleFrom[T any](registry *Registry) ([]byte, error) {
+	modules, err := exportModule(registry, reflect.TypeFor[T]())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,71 @@
+package protolizer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type Codec[T any] struct {
+	typ *Type
+}
+
+func CodecFor[T any]() (*Codec[T], error) {
+	return CodecFrom[T](_default)
+}
+
+func CodecFrom[T any](registry *Registry) (*Codec[T], error) {
+	t := reflect.TypeFor[T]()
+	typ := registry.CaptureType(t)
+	if typ == nil || typ.reflectType != t {
+		return nil, fmt.Errorf("type %s is not registered", TypeName(t))
+	}
+	return &Codec[T]{typ: typ}, nil
+}
+
+func (c *Codec[T]) Type() *Type {
+	return c.typ
+}
+
+func (c *Codec[T]) Marshal(v *T, opts ...MarshalOption) ([]byte, error) {
+	return c.MarshalAppend(make([]byte, 0), v, opts...)
+}
+
+func (c *Codec[T]) MarshalAppend(dst []byte, v *T, opts ...MarshalOption) ([]byte, error) {
+	reflected, err := c.value(v)
+	if err != nil {
+		return nil, err
+	}
+	options := acquireMarshalOptions(opts)
+	defer options.release()
+	options.Registry = c.typ.registry
+	return marshalAppend(dst, c.typ, reflected, options)
+}
+
+func (c *Codec[T]) Size(v *T) (int, error) {
+	reflected, err := c.value(v)
+	if err != nil {
+		return 0, err
+	}
+	options := acquireMarshalOptions(nil)
+	defer options.release()
+	options.Registry = c.typ.registry
+	return sizeType(c.typ, reflected, options)
+}
+
+func (c *Codec[T]) Unmarshal(bytes []byte, v *T, opts ...UnmarshalOption) error {
+	reflected, err := c.value(v)
+	if err != nil {
+		return err
+	}
+	options := acquireUnmarshalOptions(opts)
+	defer options.release()
+	options.Registry = c.typ.registry
+	return unmarshalValue(bytes, c.typ, reflected, options)
+}
+
+func (c *Codec[T]) value(v *T) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Value{}, fmt.Errorf("cannot use a nil *%s", c.typ.Name)
+	}
+	return reflect.ValueOf(v).Elem(), nil
+}
@@ -0,0 +1,22 @@
+package protolizer
+
+import "testing"
+
+func TestCodecNil(t *testing.T) {
+	codec, err := CodecFor[Person]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.Marshal(nil); err == nil {
+		t.Fatal("Marshal(nil) succeeded")
+	}
+	if _, err := codec.MarshalAppend([]byte("prefix"), nil); err == nil {
+		t.Fatal("MarshalAppend(nil) succeeded")
+	}
+	if _, err := codec.Size(nil); err == nil {
+		t.Fatal("Size(nil) succeeded")
+	}
+	if err := codec.Unmarshal([]byte{0x10, 0x01}, nil); err == nil {
+		t.Fatal("Unmarshal(nil) succeeded")
+	}
+}
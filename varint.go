@@ -1,19 +1,32 @@
 package protolizer
 
-import "fmt"
+import (
+	"fmt"
+	"math/bits"
+)
 
 func encodeVarint(value int64) []byte {
-	return encodeUvarint(uint64(value))
+	return appendVarint(nil, value)
 }
 
 func encodeUvarint(value uint64) []byte {
-	var result []byte
+	return appendUvarint(nil, value)
+}
+
+func appendVarint(out []byte, value int64) []byte {
+	return appendUvarint(out, uint64(value))
+}
+
+func appendUvarint(out []byte, value uint64) []byte {
 	for value >= 0x80 {
-		result = append(result, byte(value)|0x80)
+		out = append(out, byte(value)|0x80)
 		value >>= 7
 	}
-	result = append(result, byte(value))
-	return result
+	return append(out, byte(value))
+}
+
+func sizeUvarint(value uint64) int {
+	return (bits.Len64(value|1) + 6) / 7
 }
 
 func decodeVarint(data []byte, offset int) (int64, int, error) {
@@ -22,6 +35,13 @@ func decodeVarint(data []byte, offset int) (int64, int, error) {
 }
 
 func decodeUvarint(data []byte, offset int) (uint64, int, error) {
+	if offset < len(data) && data[offset] < 0x80 {
+		return uint64(data[offset]), 1, nil
+	}
+	return decodeLongUvarint(data, offset)
+}
+
+func decodeLongUvarint(data []byte, offset int) (uint64, int, error) {
 	var result uint64
 	var shift uint
 	pos := offset
@@ -42,3 +62,19 @@ func decodeUvarint(data []byte, offset int) (uint64, int, error) {
 	}
 	return 0, 0, fmt.Errorf("truncated varint")
 }
+
+func encodeZigZag(value int64) []byte {
+	return appendZigZag(nil, value)
+}
+
+func appendZigZag(out []byte, value int64) []byte {
+	return appendUvarint(out, uint64((value<<1)^(value>>63)))
+}
+
+func decodeZigZag(data []byte, offset int) (int64, int, error) {
+	value, consumed, err := decodeUvarint(data, offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(value>>1) ^ -int64(value&1), consumed, nil
+}
@@ -0,0 +1,183 @@
+package protolizer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+	maxDurationSeconds  = 315576000000
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	timeName     = TypeName(timeType)
+	durationName = TypeName(durationType)
+)
+
+func isWellKnown(t reflect.Type) bool {
+	return t == timeType || t == durationType
+}
+
+func appendTimestamp(out []byte, value time.Time) ([]byte, error) {
+	seconds, nanos := value.Unix(), int32(value.Nanosecond())
+	if seconds < minTimestampSeconds || seconds > maxTimestampSeconds {
+		return nil, fmt.Errorf("timestamp %v out of range", value)
+	}
+	out = appendUvarint(out, uint64(sizeSecondsNanos(seconds, nanos)))
+	return appendSecondsNanos(out, seconds, nanos), nil
+}
+
+func sizeTimestamp(value time.Time) (int, error) {
+	seconds, nanos := value.Unix(), int32(value.Nanosecond())
+	if seconds < minTimestampSeconds || seconds > maxTimestampSeconds {
+		return 0, fmt.Errorf("timestamp %v out of range", value)
+	}
+	return sizeBytes(sizeSecondsNanos(seconds, nanos)), nil
+}
+
+func decodeTimestamp(data []byte, offset int, options *UnmarshalOptions) (time.Time, int, error) {
+	seconds, nanos, consumed, err := decodeSecondsNanos(data, offset, options)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if seconds < minTimestampSeconds || seconds > maxTimestampSeconds || nanos < 0 || nanos > 999999999 {
+		return time.Time{}, 0, fmt.Errorf("timestamp (%d, %d) out of range", seconds, nanos)
+	}
+	return time.Unix(seconds, int64(nanos)).UTC(), consumed, nil
+}
+
+func appendDuration(out []byte, value time.Duration) []byte {
+	seconds, nanos := int64(value/time.Second), int32(value%time.Second)
+	out = appendUvarint(out, uint64(sizeSecondsNanos(seconds, nanos)))
+	return appendSecondsNanos(out, seconds, nanos)
+}
+
+func sizeDuration(value time.Duration) int {
+	return sizeBytes(sizeSecondsNanos(int64(value/time.Second), int32(value%time.Second)))
+}
+
+func decodeDuration(data []byte, offset int, options *UnmarshalOptions) (time.Duration, int, error) {
+	seconds, nanos, consumed, err := decodeSecondsNanos(data, offset, options)
+	if err != nil {
+		return 0, 0, err
+	}
+	if seconds < -maxDurationSeconds || seconds > maxDurationSeconds || nanos < -999999999 || nanos > 999999999 || seconds > 0 && nanos < 0 || seconds < 0 && nanos > 0 {
+		return 0, 0, fmt.Errorf("duration (%d, %d) out of range", seconds, nanos)
+	}
+	value := time.Duration(seconds)*time.Second + time.Duration(nanos)
+	if seconds > int64(math.MaxInt64/time.Second) || seconds < int64(math.MinInt64/time.Second) || seconds > 0 && value < 0 || seconds < 0 && value > 0 {
+		return 0, 0, fmt.Errorf("duration (%d, %d) overflows time.Duration", seconds, nanos)
+	}
+	return value, consumed, nil
+}
+
+func appendSecondsNanos(out []byte, seconds int64, nanos int32) []byte {
+	if seconds != 0 {
+		out, _ = appendTag(out, 1, WireTypeVarint)
+		out = appendVarint(out, seconds)
+	}
+	if nanos != 0 {
+		out, _ = appendTag(out, 2, WireTypeVarint)
+		out = appendVarint(out, int64(nanos))
+	}
+	return out
+}
+
+func sizeSecondsNanos(seconds int64, nanos int32) int {
+	out := 0
+	if seconds != 0 {
+		out += sizeTag(1) + sizeUvarint(uint64(seconds))
+	}
+	if nanos != 0 {
+		out += sizeTag(2) + sizeUvarint(uint64(int64(nanos)))
+	}
+	return out
+}
+
+func decodeSecondsNanos(data []byte, offset int, options *UnmarshalOptions) (int64, int32, int, error) {
+	value, consumed, err := viewBytes(data, offset)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var seconds int64
+	var nanos int32
+	pos := 0
+	for pos < len(value) {
+		fieldNum, wireType, c, err := decodeTag(value, pos)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		pos += c
+		if wireType != WireTypeVarint || fieldNum != 1 && fieldNum != 2 {
+			c, err := skipValue(value, pos, fieldNum, wireType, options)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			pos += c
+			continue
+		}
+		number, c, err := decodeVarint(value, pos)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		pos += c
+		if fieldNum == 1 {
+			seconds = number
+			continue
+		}
+		nanos = int32(number)
+	}
+	return seconds, nanos, consumed, nil
+}
+
+func formatDuration(value time.Duration) string {
+	seconds, nanos := int64(value/time.Second), int64(value%time.Second)
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign, seconds, nanos = "-", -seconds, -nanos
+	}
+	out := fmt.Sprintf("%s%d", sign, seconds)
+	if nanos != 0 {
+		fraction := fmt.Sprintf("%09d", nanos)
+		for strings.HasSuffix(fraction, "000") {
+			fraction = strings.TrimSuffix(fraction, "000")
+		}
+		out += "." + fraction
+	}
+	return out + "s"
+}
+
+func parseTimestamp(v reflect.Value) (time.Time, error) {
+	switch value := v.Interface().(type) {
+	case time.Time:
+		{
+			return value, nil
+		}
+	case string:
+		{
+			return time.Parse(time.RFC3339Nano, value)
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a time.Time or an RFC 3339 string but got %v", v.Type())
+}
+
+func parseDuration(v reflect.Value) (time.Duration, error) {
+	switch v.Kind() {
+	case reflect.String:
+		{
+			return time.ParseDuration(v.String())
+		}
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int8:
+		{
+			return time.Duration(v.Int()), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a time.Duration or a duration string but got %v", v.Type())
+}
@@ -0,0 +1,80 @@
+package protolizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type wellKnownMessage struct {
+	At      time.Time     `protobuf:"bytes,1,opt,name=at,proto3"`
+	Timeout time.Duration `protobuf:"bytes,2,opt,name=timeout,proto3"`
+	Nanos   time.Duration `protobuf:"varint,3,opt,name=nanos,proto3"`
+	Since   *time.Time    `protobuf:"bytes,4,opt,name=since,proto3"`
+}
+
+func init() {
+	RegisterTypeFor[wellKnownMessage]()
+}
+
+func TestWellKnownGolden(t *testing.T) {
+	zero := time.Time{}
+	tests := []struct {
+		name  string
+		value *wellKnownMessage
+		want  string
+		read  map[string]any
+	}{
+		{"unset", &wellKnownMessage{}, "", map[string]any{}},
+		{"epoch", &wellKnownMessage{At: time.Unix(0, 0).UTC()}, "0a00", map[string]any{"At": "1970-01-01T00:00:00Z", PresentFieldsKey: []string{"At"}}},
+		{"nanos", &wellKnownMessage{At: time.Date(2024, 3, 1, 12, 30, 0, 5, time.UTC)}, "0a0808c89487af061005", map[string]any{"At": "2024-03-01T12:30:00.000000005Z", PresentFieldsKey: []string{"At"}}},
+		{"before epoch", &wellKnownMessage{At: time.Unix(-1, 500000000).UTC()}, "0a1108ffffffffffffffffff011080cab5ee01", map[string]any{"At": "1969-12-31T23:59:59.5Z", PresentFieldsKey: []string{"At"}}},
+		{"maximum", &wellKnownMessage{At: time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)}, "0a0d08ff82d1ffaf0710ff93ebdc03", map[string]any{"At": "9999-12-31T23:59:59.999999999Z", PresentFieldsKey: []string{"At"}}},
+		{"zero time", &wellKnownMessage{Since: &zero}, "220b088092b8c398feffffff01", map[string]any{"Since": "0001-01-01T00:00:00Z", PresentFieldsKey: []string{"Since"}}},
+		{"duration", &wellKnownMessage{Timeout: 1500 * time.Millisecond}, "120808011080cab5ee01", map[string]any{"Timeout": "1.500s"}},
+		{"negative duration", &wellKnownMessage{Timeout: -1500 * time.Millisecond}, "121608ffffffffffffffffff011080b6ca91feffffffff01", map[string]any{"Timeout": "-1.500s"}},
+		{"negative nanos", &wellKnownMessage{Timeout: -time.Nanosecond}, "120b10ffffffffffffffffff01", map[string]any{"Timeout": "-0.000000001s"}},
+		{"whole seconds", &wellKnownMessage{Timeout: time.Hour}, "120308901c", map[string]any{"Timeout": "3600s"}},
+		{"plain duration", &wellKnownMessage{Nanos: 1500}, "18dc0b", map[string]any{"Nanos": float64(1500)}},
+	}
+	name := TypeName(reflect.TypeFor[wellKnownMessage]())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[wellKnownMessage](t, want)
+			got, err := Read(name, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.read) {
+				t.Fatalf("Read = %#v, want %#v", got, tt.read)
+			}
+		})
+	}
+}
+
+func TestWellKnownInvalid(t *testing.T) {
+	if _, err := Marshal(&wellKnownMessage{At: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Fatal("expected an error for a timestamp after 9999")
+	}
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"timestamp nanos overflow", "0a06108094ebdc03"},
+		{"timestamp negative nanos", "0a0b10ffffffffffffffffff01"},
+		{"duration mixed signs", "120d080110ffffffffffffffffff01"},
+		{"duration out of range", "12070881bcaece9709"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal(decodeHex(t, tt.data), new(wellKnownMessage))
+			if err == nil || !strings.Contains(err.Error(), "out of range") {
+				t.Fatalf("got %v, want an out of range error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package protolizer
+
+import (
+	"reflect"
+)
+
+func isWrapper(f reflect.StructField, field *Field) bool {
+	info := field.Tags.Protobuf
+	if info == nil || info.WireType != WireTypeLen || info.Label == "rep" {
+		return false
+	}
+	if _, ok := f.Tag.Lookup("protobuf_wrapper"); ok {
+		return isNumeric(field.Kind) || field.Kind == reflect.String || field.Kind == reflect.Slice && field.Index == reflect.Uint8
+	}
+	return field.IsPointer && f.Type.Elem() != durationType && isNumeric(field.Kind)
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	return isInteger(kind) || kind == reflect.Bool || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isZeroWrapped(v reflect.Value) bool {
+	return v.IsZero() || v.Kind() == reflect.Slice && v.Len() == 0
+}
+
+func wrappedInfo(kind reflect.Kind) *ProtobufInfo {
+	out := &ProtobufInfo{FieldNum: 1, Label: "opt", Syntax: "proto3"}
+	switch kind {
+	case reflect.Float32:
+		{
+			out.WireType = WireTypeI32
+		}
+	case reflect.Float64:
+		{
+			out.WireType = WireTypeI64
+		}
+	case reflect.String, reflect.Array, reflect.Slice:
+		{
+			out.WireType = WireTypeLen
+		}
+	}
+	return out
+}
+
+func encodeWrapper(out []byte, v *reflect.Value, kind reflect.Kind, options *MarshalOptions) ([]byte, error) {
+	if isZeroWrapped(*v) {
+		return appendBytes(out, nil), nil
+	}
+	info := wrappedInfo(kind)
+	out, start := options.beginLength(out)
+	out, err := appendTag(out, 1, info.WireType)
+	if err != nil {
+		return nil, err
+	}
+	out, err = encodeValue(out, v, kind, info, options)
+	if err != nil {
+		return nil, err
+	}
+	return options.endLength(out, start), nil
+}
+
+func sizeWrapper(v reflect.Value, kind reflect.Kind, options *MarshalOptions) (int, error) {
+	if isZeroWrapped(v) {
+		return sizeBytes(0), nil
+	}
+	info := wrappedInfo(kind)
+	index := options.cache.reserve()
+	n, err := sizeValue(v, kind, info, options)
+	if err != nil {
+		return 0, err
+	}
+	return options.cache.set(index, sizeTag(1)+n), nil
+}
+
+func decodeWrapper(v *reflect.Value, kind reflect.Kind, bytes []byte, pos int, options *UnmarshalOptions) (int, error) {
+	value, c, err := viewBytes(bytes, pos)
+	if err != nil {
+		return pos, err
+	}
+	elem, _ := dereference(v)
+	if kind == reflect.Slice && elem.IsNil() {
+		elem.SetBytes([]byte{})
+	}
+	info := wrappedInfo(kind)
+	innerPos := 0
+	for innerPos < len(value) {
+		fieldNum, wireType, consumed, err := decodeTag(value, innerPos)
+		if err != nil {
+			return pos, err
+		}
+		innerPos += consumed
+		if fieldNum != 1 {
+			consumed, err := skipValue(value, innerPos, fieldNum, wireType, options)
+			if err != nil {
+				return pos, err
+			}
+			innerPos += consumed
+			continue
+		}
+		innerPos, err = decodeValue(elem, kind, value, wireType, info, innerPos, options)
+		if err != nil {
+			return pos, err
+		}
+	}
+	return pos + c, nil
+}
+
+func encodeWrapperAnonymous(v *reflect.Value, field *Field, options *MarshalOptions) ([]byte, error) {
+	if isZeroWrapped(*v) {
+		return encodeBytes(nil), nil
+	}
+	info := wrappedInfo(field.Kind)
+	data, err := encodeValueAnonymous(v, field, field.Kind, info, options)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBytes(appendField(nil, 1, info.WireType, data)), nil
+}
+
+func decodeWrapperAnonymous(field *Field, bytes []byte, pos int, options *UnmarshalOptions) (any, int, error) {
+	value, c, err := viewBytes(bytes, pos)
+	if err != nil {
+		return nil, pos, err
+	}
+	info := wrappedInfo(field.Kind)
+	out, err := zeroAnonymous(field, info, options)
+	if err != nil {
+		return nil, pos, err
+	}
+	innerPos := 0
+	for innerPos < len(value) {
+		fieldNum, wireType, consumed, err := decodeTag(value, innerPos)
+		if err != nil {
+			return nil, pos, err
+		}
+		innerPos += consumed
+		if fieldNum != 1 {
+			consumed, err := skipValue(value, innerPos, fieldNum, wireType, options)
+			if err != nil {
+				return nil, pos, err
+			}
+			innerPos += consumed
+			continue
+		}
+		out, innerPos, err = decodeValueAnonymous(field, value, wireType, info, innerPos, options)
+		if err != nil {
+			return nil, pos, err
+		}
+	}
+	return out, pos + c, nil
+}
@@ -0,0 +1,113 @@
+package protolizer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type wrapperMessage struct {
+	Double *float64 `protobuf:"bytes,1,opt,name=double,proto3"`
+	Float  *float32 `protobuf:"bytes,2,opt,name=float,proto3"`
+	Int64  *int64   `protobuf:"bytes,3,opt,name=int64,proto3"`
+	Uint64 *uint64  `protobuf:"bytes,4,opt,name=uint64,proto3"`
+	Int32  *int32   `protobuf:"bytes,5,opt,name=int32,proto3"`
+	Uint32 *uint32  `protobuf:"bytes,6,opt,name=uint32,proto3"`
+	Bool   *bool    `protobuf:"bytes,7,opt,name=bool,proto3"`
+	String *string  `protobuf:"bytes,8,opt,name=string,proto3" protobuf_wrapper:"google.protobuf.StringValue"`
+	Bytes  []byte   `protobuf:"bytes,9,opt,name=bytes,proto3" protobuf_wrapper:"google.protobuf.BytesValue"`
+}
+
+func init() {
+	RegisterTypeFor[wrapperMessage]()
+}
+
+func TestWrapperGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *wrapperMessage
+		want  string
+	}{
+		{"nil", &wrapperMessage{}, ""},
+		{"zero", &wrapperMessage{Double: new(float64), Float: new(float32), Int64: new(int64), Uint64: new(uint64), Int32: new(int32), Uint32: new(uint32), Bool: new(bool), String: new(string), Bytes: []byte{}}, "0a0012001a0022002a0032003a0042004a00"},
+		{"set", &wrapperMessage{Double: ptr(-2.5), Float: ptr[float32](1.5), Int64: ptr[int64](-3), Uint64: ptr[uint64](4), Int32: ptr[int32](-5), Uint32: ptr[uint32](6), Bool: ptr(true), String: ptr("s"), Bytes: []byte{1}}, "0a090900000000000004c012050d0000c03f1a0b08fdffffffffffffffff01220208042a0b08fbffffffffffffffff01320208063a02080142030a01734a030a0101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			assertReadWrite[wrapperMessage](t, want)
+		})
+	}
+}
+
+func TestWrapperRead(t *testing.T) {
+	name := TypeName(reflect.TypeFor[wrapperMessage]())
+	tests := []struct {
+		name string
+		data string
+		want map[string]any
+	}{
+		{"nil", "", map[string]any{}},
+		{"zero", "0a0012001a0022002a0032003a0042004a00", map[string]any{
+			PresentFieldsKey: []string{"Double", "Float", "Int64", "Uint64", "Int32", "Uint32", "Bool", "String", "Bytes"},
+			"Double":         float64(0),
+			"Float":          float64(0),
+			"Int64":          float64(0),
+			"Uint64":         float64(0),
+			"Int32":          float64(0),
+			"Uint32":         float64(0),
+			"Bool":           false,
+			"String":         "",
+			"Bytes":          []byte{},
+		}},
+		{"set", "0a090900000000000004c012050d0000c03f1a0b08fdffffffffffffffff01220208042a0b08fbffffffffffffffff01320208063a02080142030a01734a030a0101", map[string]any{
+			PresentFieldsKey: []string{"Double", "Float", "Int64", "Uint64", "Int32", "Uint32", "Bool", "String", "Bytes"},
+			"Double":         -2.5,
+			"Float":          1.5,
+			"Int64":          float64(-3),
+			"Uint64":         float64(4),
+			"Int32":          float64(-5),
+			"Uint32":         float64(6),
+			"Bool":           true,
+			"String":         "s",
+			"Bytes":          []byte{1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(name, decodeHex(t, tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Read = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapperWrite(t *testing.T) {
+	name := TypeName(reflect.TypeFor[wrapperMessage]())
+	tests := []struct {
+		name  string
+		value map[string]any
+		want  string
+	}{
+		{"absent", map[string]any{}, ""},
+		{"zero", map[string]any{"Int32": 0, "Bool": false, "String": "", "Bytes": []byte{}}, "2a003a0042004a00"},
+		{"set", map[string]any{"Uint32": 6, "Bytes": []byte{1}}, "320208064a030a0101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Write(name, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeHex(t, tt.want); !bytes.Equal(got, want) {
+				t.Fatalf("Write = %x, want %x", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package protolizer
+
+import (
+	"math"
+	"testing"
+)
+
+type zigzagMessage struct {
+	S32    int32           `protobuf:"zigzag32,1,opt,name=s32,proto3"`
+	S64    int64           `protobuf:"zigzag64,2,opt,name=s64,proto3"`
+	Packed []int32         `protobuf:"zigzag32,3,rep,packed,name=packed,proto3"`
+	Keys   map[int64]int32 `protobuf:"bytes,4,rep,name=keys,proto3" protobuf_key:"zigzag64,1,opt,name=key" protobuf_val:"zigzag32,2,opt,name=value"`
+}
+
+func init() {
+	RegisterTypeFor[zigzagMessage]()
+}
+
+func TestZigZagGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *zigzagMessage
+		want  string
+		lossy bool
+	}{
+		{"empty", &zigzagMessage{}, "", false},
+		{"negative one", &zigzagMessage{S32: -1, S64: -1}, "08011001", false},
+		{"positive", &zigzagMessage{S32: 1}, "0802", false},
+		{"minimum", &zigzagMessage{S32: math.MinInt32, S64: math.MinInt64}, "08ffffffff0f10ffffffffffffffffff01", false},
+		{"maximum", &zigzagMessage{S32: math.MaxInt32, S64: math.MaxInt64}, "08feffffff0f10feffffffffffffffff01", true},
+		{"packed", &zigzagMessage{Packed: []int32{-1, 0, 1, -64, 64}}, "1a060100027f8001", false},
+		{"map", &zigzagMessage{Keys: map[int64]int32{-2: -3}}, "220408031005", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := decodeHex(t, tt.want)
+			assertMarshal(t, tt.value, want)
+			assertUnmarshal(t, want, tt.value)
+			if !tt.lossy {
+				assertReadWrite[zigzagMessage](t, want)
+			}
+		})
+	}
+}